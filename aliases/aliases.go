@@ -0,0 +1,227 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+// Package aliases makes the redirects from the old urls of moved or
+// renamed blog entries and pages to their current ones. Each old url
+// gets a small HTML page that sends browsers on, and the same list can
+// be written out for web servers that handle redirects themselves.
+package aliases
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Formats are the redirect files MakeRedirects knows how to write and
+// the names of the files they are written to.
+var Formats = map[string]string{
+	"netlify": "_redirects",
+	"nginx":   "redirects.nginx.conf",
+	"apache":  ".htaccess",
+}
+
+// Alias is an old url of a page and the url it has now.
+type Alias struct {
+	// From is the old url from the root of the site (e.g. old.html or
+	// 2013/old/).
+	From string
+
+	// To is the current url from the root of the site, like the Url of
+	// a blog entry.
+	To string
+}
+
+// New returns the Alias from the given old url to the given one. The
+// old url may start with a slash and, like a permalink, it's a
+// directory when it has no extension.
+func New(from, to string) Alias {
+	from = strings.TrimPrefix(path.Clean("/"+strings.TrimSpace(from)), "/")
+	if from != "" && path.Ext(from) == "" {
+		from += "/"
+	}
+
+	return Alias{From: from, To: to}
+}
+
+// Valid returns true if the old url of the Alias can be redirected
+// from. The redirect files are split up on whitespace, so it can't
+// have any.
+func (a Alias) Valid() bool {
+	return strings.IndexFunc(a.From, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	}) == -1
+}
+
+// File returns the file the redirect page is written to, relative to
+// the output directory.
+func (a Alias) File() string {
+	if a.From == "" || strings.HasSuffix(a.From, "/") {
+		return a.From + "index.html"
+	}
+
+	return a.From
+}
+
+// page is the redirect page. The canonical link tells search engines
+// where the page went.
+var page = template.Must(template.New("alias").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>{{.}}</title>
+  <link rel="canonical" href="{{.}}">
+  <meta http-equiv="refresh" content="0; url={{.}}">
+</head>
+<body>
+  <p>This page has moved to <a href="{{.}}">{{.}}</a>.</p>
+</body>
+</html>
+`))
+
+// MakePage writes the redirect page of the given Alias into the given
+// directory. siteUrl is the url of the site; the page sends browsers
+// to the full url when there is one.
+// 在旧的地址生成一个跳转到新地址的页面
+func MakePage(dir string, a Alias, siteUrl string) error {
+	to := sitePath(siteUrl) + a.To
+	if siteUrl != "" {
+		to = strings.TrimSuffix(siteUrl, "/") + "/" + a.To
+	}
+
+	file := path.Join(dir, a.File())
+	err := os.MkdirAll(path.Dir(file), 0750)
+	if err != nil {
+		return err
+	}
+
+	sw := new(bytes.Buffer)
+	err = page.Execute(sw, to)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, sw.Bytes(), 0644)
+}
+
+// MakeRedirects writes the given aliases as permanent redirects in the
+// given format (one of Formats) into the given directory. The urls in
+// it are paths on the server, so they start with the path of siteUrl.
+// 生成 web 服务器使用的跳转配置文件
+func MakeRedirects(dir, format string, list []Alias, siteUrl string) error {
+	name, ok := Formats[format]
+	if !ok {
+		return fmt.Errorf("unknown redirects format %s", format)
+	}
+
+	sw := new(bytes.Buffer)
+	err := writeRedirects(sw, format, list, sitePath(siteUrl))
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path.Join(dir, name), sw.Bytes(), 0644)
+}
+
+// writeRedirects writes the aliases in the given format to w. base is
+// the path of the site on the server. The old urls are matched the way
+// each server sees them and the new ones are escaped, so an old url
+// that is a directory is redirected with or without its slash.
+func writeRedirects(w io.Writer, format string, list []Alias,
+	base string) error {
+
+	var err error
+	switch format {
+	case "nginx":
+		// It's a map to include in the http block, used in the server
+		// block with: if ($goblog_redirect) { return 301 $goblog_redirect; }
+		// The $uri it maps is decoded, so the old urls are too.
+		_, err = fmt.Fprintln(w, "map $uri $goblog_redirect {")
+		for _, a := range list {
+			from := []string{base + a.From}
+			if strings.HasSuffix(a.From, "/") {
+				from = []string{strings.TrimSuffix(base+a.From, "/"), base + a.From}
+			}
+			for _, f := range from {
+				if err != nil {
+					break
+				}
+				_, err = fmt.Fprintf(w, "    %s %s;\n", nginxQuote(f),
+					nginxQuote(target(base, a.To)))
+			}
+		}
+		if err == nil {
+			_, err = fmt.Fprintln(w, "}")
+		}
+	case "apache":
+		// RedirectMatch matches the whole of the decoded path, unlike
+		// Redirect, which would send everything under it along too.
+		for _, a := range list {
+			if err != nil {
+				break
+			}
+			from := "^" + regexp.QuoteMeta(base+a.From) + "$"
+			if strings.HasSuffix(a.From, "/") {
+				from = "^" + regexp.QuoteMeta(strings.TrimSuffix(base+a.From, "/")) +
+					"/?$"
+			}
+			_, err = fmt.Fprintf(w, "RedirectMatch 301 %s %s\n",
+				apacheQuote(from), apacheQuote(target(base, a.To)))
+		}
+	default:
+		// Netlify matches the escaped path and ignores the slash at
+		// the end itself.
+		for _, a := range list {
+			if err != nil {
+				break
+			}
+			_, err = fmt.Fprintf(w, "%s %s 301\n", escape(base+a.From),
+				target(base, a.To))
+		}
+	}
+
+	return err
+}
+
+// escape returns the path p escaped for a url.
+func escape(p string) string {
+	return (&url.URL{Path: p}).EscapedPath()
+}
+
+// target returns the escaped path of the new url to on the site at
+// base. A $ starts a variable or a backreference in the server
+// configurations, so it's escaped too.
+func target(base, to string) string {
+	return strings.Replace(escape(base+to), "$", "%24", -1)
+}
+
+// nginxQuote returns s in the double quotes of an nginx configuration.
+func nginxQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// apacheQuote returns s in the double quotes of an Apache
+// configuration, where only a quote is escaped.
+func apacheQuote(s string) string {
+	return `"` + strings.Replace(s, `"`, `\"`, -1) + `"`
+}
+
+// sitePath returns the path of the site url (e.g. /blog/ for
+// http://example.com/blog). It's / when there isn't one.
+func sitePath(siteUrl string) string {
+	u, err := url.Parse(siteUrl)
+	if err != nil || u.Path == "" {
+		return "/"
+	}
+
+	return strings.TrimSuffix(u.Path, "/") + "/"
+}
@@ -0,0 +1,91 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package aliases
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestNew tests the old urls and files of aliases.
+func TestNew(t *testing.T) {
+	tests := []struct {
+		from      string
+		url, file string
+	}{
+		{"old.html", "old.html", "old.html"},
+		{"/2013/old.html", "2013/old.html", "2013/old.html"},
+		{"/2013/04/old", "2013/04/old/", "2013/04/old/index.html"},
+		{"old/", "old/", "old/index.html"},
+	}
+
+	for i, test := range tests {
+		a := New(test.from, "new/")
+		if a.From != test.url || a.File() != test.file {
+			t.Errorf("(%d) expecting %s, %s but got %s, %s", i, test.url,
+				test.file, a.From, a.File())
+		}
+	}
+}
+
+// TestValid tests the old urls that can't be written to the redirect
+// files.
+func TestValid(t *testing.T) {
+	tests := []struct {
+		from     string
+		expected bool
+	}{
+		{"old.html", true},
+		{" /2013/old/ ", true},
+		{"my old.html", false},
+		{"old\nRedirect 301 / /evil/", false},
+		{"old\t.html", false},
+	}
+
+	for i, test := range tests {
+		if v := New(test.from, "new/").Valid(); v != test.expected {
+			t.Errorf("(%d) expecting %v for %q but got %v", i, test.expected,
+				test.from, v)
+		}
+	}
+}
+
+// TestWriteRedirects tests each of the redirect formats.
+func TestWriteRedirects(t *testing.T) {
+	list := []Alias{New("old.html", "new/"), New("2013/a", "b.html"),
+		New(`"q$".html`, "ü b/")}
+
+	tests := []struct {
+		format, base string
+		expected     string
+	}{
+		{"netlify", "/", "/old.html /new/ 301\n/2013/a/ /b.html 301\n" +
+			"/%22q$%22.html /%C3%BC%20b/ 301\n"},
+		{"apache", "/blog/",
+			`RedirectMatch 301 "^/blog/old\.html$" "/blog/new/"` + "\n" +
+				`RedirectMatch 301 "^/blog/2013/a/?$" "/blog/b.html"` + "\n" +
+				`RedirectMatch 301 "^/blog/\"q\$\"\.html$" "/blog/%C3%BC%20b/"` +
+				"\n"},
+		{"nginx", "/", "map $uri $goblog_redirect {\n" +
+			`    "/old.html" "/new/";` + "\n" +
+			`    "/2013/a" "/b.html";` + "\n" +
+			`    "/2013/a/" "/b.html";` + "\n" +
+			`    "/\"q$\".html" "/%C3%BC%20b/";` + "\n}\n"},
+	}
+
+	for i, test := range tests {
+		sw := new(bytes.Buffer)
+		err := writeRedirects(sw, test.format, list, test.base)
+		if err != nil {
+			t.Errorf("(%d) unexpected error: %s", i, err)
+			continue
+		}
+
+		if sw.String() != test.expected {
+			t.Errorf("(%d) expecting %q but got %q", i, test.expected,
+				sw.String())
+		}
+	}
+}
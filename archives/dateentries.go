@@ -58,17 +58,11 @@ func (de DateEntries) Add(year, month string, e *blogs.BlogEntry) {
 	y, ok := de[year]
 	if !ok {
 		// We need to create it.
-		de[year] = &YearEntries{
+		y = &YearEntries{
 			Year: year,
-			Months: []*MonthEntries{
-				&MonthEntries{
-					Month:   month,
-					Entries: []*blogs.BlogEntry{e},
-				},
-			},
+			Url:  year + "/",
 		}
-
-		return
+		de[year] = y
 	}
 
 	y.Add(month, e)
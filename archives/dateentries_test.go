@@ -0,0 +1,54 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package archives
+
+import (
+	"github.com/pyanfield/goblog/blogs"
+	"testing"
+	"time"
+)
+
+// TestParseBlogsUrls tests the urls of the years and months and the
+// order of the months returned by Months.
+func TestParseBlogsUrls(t *testing.T) {
+	dated := []*blogs.BlogEntry{
+		&blogs.BlogEntry{Created: time.Date(2013, 4, 2, 0, 0, 0, 0, time.UTC)},
+		&blogs.BlogEntry{Created: time.Date(2012, 11, 5, 0, 0, 0, 0, time.UTC)},
+		&blogs.BlogEntry{Created: time.Date(2013, 1, 9, 0, 0, 0, 0, time.UTC)},
+		&blogs.BlogEntry{Created: time.Date(2013, 4, 20, 0, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		year, month, url string
+		entries          int
+	}{
+		{"2013", "April", "2013/04/", 2},
+		{"2013", "January", "2013/01/", 1},
+		{"2012", "November", "2012/11/", 1},
+	}
+
+	years := ParseBlogs(dated).Slice()
+	if len(years) != 2 || years[0].Url != "2013/" || years[1].Url != "2012/" {
+		t.Fatalf("expecting the years 2013/ and 2012/ but got %+v", years)
+	}
+
+	months := years.Months()
+	if len(months) != len(tests) {
+		t.Fatalf("expecting %d months but got %d", len(tests), len(months))
+	}
+
+	for i, test := range tests {
+		m := months[i]
+		if m.Year != test.year || m.Month != test.month || m.Url != test.url {
+			t.Errorf("(%d) expecting %s, %s, %s but got %s, %s, %s", i,
+				test.year, test.month, test.url, m.Year, m.Month, m.Url)
+		}
+
+		if len(m.Entries) != test.entries {
+			t.Errorf("(%d) expecting %d entries but got %d", i,
+				test.entries, len(m.Entries))
+		}
+	}
+}
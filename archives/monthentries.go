@@ -15,6 +15,13 @@ type MonthEntries struct {
 	// The name of the month.
 	Month string
 
+	// Year is the name of the year the month is in.
+	Year string
+
+	// Url is the url of the month's page from the root of the site
+	// (e.g. "2013/04/").
+	Url string
+
 	// A list of blog entries for this month.
 	Entries []*blogs.BlogEntry
 }
@@ -5,6 +5,7 @@
 package archives
 
 import (
+	"fmt"
 	"github.com/pyanfield/goblog/blogs"
 )
 
@@ -31,6 +32,10 @@ type YearEntries struct {
 	// The name of the year.
 	Year string
 
+	// Url is the url of the year's page from the root of the site
+	// (e.g. "2013/").
+	Url string
+
 	// A list of MonthEntries for this year.
 	Months []*MonthEntries
 }
@@ -57,6 +62,8 @@ func (ye *YearEntries) Add(month string, e *blogs.BlogEntry) {
 		// We didn't find a month, so let's make it
 		ye.Months = append(ye.Months, &MonthEntries{
 			Month:   month,
+			Year:    ye.Year,
+			Url:     fmt.Sprintf("%s/%02d/", ye.Year, mstrs[month]),
 			Entries: []*blogs.BlogEntry{e},
 		})
 	} else {
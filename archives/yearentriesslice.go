@@ -8,8 +8,21 @@ import (
 	"strconv"
 )
 
+// YearEntriesSlice is a list of YearEntries. It implements the sort
+// interface for sorting the years by date descending.
 type YearEntriesSlice []*YearEntries
 
+// Months returns the months of every year in the order they are in,
+// newest first once the slice is sorted.
+func (ye YearEntriesSlice) Months() []*MonthEntries {
+	months := []*MonthEntries{}
+	for _, y := range ye {
+		months = append(months, y.Months...)
+	}
+
+	return months
+}
+
 // Len returns the length of the YearEntriesSlice.
 func (ye YearEntriesSlice) Len() int {
 	return len(ye)
@@ -8,14 +8,17 @@ package blogs
 
 import (
 	"bytes"
-	// "fmt"
+	"fmt"
 	"github.com/pyanfield/goblog/fs"
 	md "github.com/russross/blackfriday"
+	"html"
+	"html/template"
 	"io/ioutil"
 	"path"
 	"regexp"
 	"strings"
 	"time"
+	"unicode"
 )
 
 // BlogEntry is a representation of a blog entry. It contains the
@@ -37,9 +40,29 @@ type BlogEntry struct {
 	// Description is the description of the Entry.
 	Description string
 
-	// Url is the HTML file name of this entry (Name + ".html").
+	// Url is the url of this entry from the root of the site. It is
+	// Name + ".html" until it's replaced by the site's Permalink.
 	Url string
 
+	// Slug is the name of the entry used in its Permalink. It is the
+	// name of its file unless the Slug metadata says otherwise.
+	Slug string
+
+	// Enclosure is the media file of the entry (e.g. the audio of a
+	// podcast episode), or nil. It is generated when the Parse method
+	// is called.
+	Enclosure *Enclosure
+
+	// Aliases are the old urls of the entry (e.g. old-name.html) that
+	// redirect to its Url. It is generated when the Parse method is
+	// called.
+	Aliases []string
+
+	// Section is the first directory of the entry in the blog
+	// directory (e.g. "golang" for golang/intro.md), or "" for the
+	// entries at the top of it.
+	Section string
+
 	// Tags is a list of tags this blog entry contains. It is generated
 	// when when the Parse method is called.
 	Tags []string
@@ -49,17 +72,71 @@ type BlogEntry struct {
 	Languages []string
 
 	// Created is the date the blog entry was created. It is generated
-	// when the Parse metod is called.
+	// when the Parse metod is called. It comes from the Date metadata
+	// if there is one, otherwise from git or the file system.
 	Created time.Time
 
 	// Updated is the date the blog entry was last updated. It is
-	// generated when the Parse metod is called.
+	// generated when the Parse metod is called. It comes from the
+	// Updated metadata if there is one, otherwise from git or the file
+	// system.
 	Updated time.Time
+
+	// Draft marks the entry as unfinished. Drafts are only rendered
+	// when the build asks for them.
+	Draft bool
+
+	// PublishDate hides the entry until the given time. It is ignored
+	// when it's the zero time.
+	PublishDate time.Time
+
+	// ExpiryDate hides the entry once the given time has passed. It is
+	// ignored when it's the zero time.
+	ExpiryDate time.Time
+
+	// NoSitemap leaves the entry out of the sitemap. It comes from the
+	// Sitemap metadata being "exclude".
+	NoSitemap bool
+
+	// Content is the HTML generated from the markdown. It is cached
+	// here when the Parse method is called so the entry never has to
+	// be parsed twice. It is trusted HTML, so templates output it as it
+	// is.
+	Content template.HTML
+
+	// Params holds any metadata that doesn't belong to one of the
+	// fields above (e.g. a hero image or a series name). The keys are
+	// always lower case so templates can use them as .Params.hero. It
+	// is generated when the Parse method is called.
+	Params map[string]interface{}
+}
+
+// knownKeys are the metadata keys that are stored in their own
+// BlogEntry fields. Every other key ends up in Params.
+var knownKeys = map[string]bool{
+	"title":       true,
+	"author":      true,
+	"description": true,
+	"tags":        true,
+	"languages":   true,
+	"draft":       true,
+	"publishdate": true,
+	"expirydate":  true,
+	"date":        true,
+	"updated":     true,
+	"slug":        true,
+	"aliases":     true,
+	"enclosure":   true,
+	"sitemap":     true,
 }
 
 // Parse reads the contents of the path for this BlogEntry. It gleans
 // information from the file and saves it to this BlogEntry. It then
-// formats the markdown to HTML and returns that.
+// formats the markdown to HTML and returns that. The information may
+// come from a leading YAML ("---") or TOML ("+++") front matter block,
+// from HTML comments like <!--Title: ...-->, or both. When a value is
+// given in both places, the front matter is used. The HTML is also
+// saved as the Content of this BlogEntry.
 // 根据 BlogEntry的文件path路径信息，读取文件的内容。
 func (be *BlogEntry) Parse() (string, error) {
 	// Get the files contents.
@@ -69,15 +146,33 @@ func (be *BlogEntry) Parse() (string, error) {
 		return "", err
 	}
 
+	// Pull off the YAML or TOML front matter if there is any. The
+	// front matter is never handed to the markdown processor.
+	// 分离出文件开头的 YAML 或者 TOML 元数据
+	meta, body := splitFrontMatter(orgContents)
+
 	// Save some of the meta data.
 	// 获取md文件信息，包括 title ,author等等
-	err = be.gleanInfo(string(orgContents))
+	err = be.gleanInfo(string(body))
+	if err != nil {
+		return "", err
+	}
+
+	// The front matter wins over the HTML comments.
+	err = be.applyFrontMatter(meta)
+	if err != nil {
+		return "", err
+	}
+
+	// Fill in the dates that weren't given explicitly.
+	err = be.lookupTimes()
 	if err != nil {
 		return "", err
 	}
 
 	// Return the markdown content.
-	return string(md.MarkdownCommon(orgContents)), nil
+	be.Content = template.HTML(md.MarkdownCommon(body))
+	return string(be.Content), nil
 }
 
 // CDate is a helper function for the templating system that returns
@@ -119,8 +214,9 @@ func (be *BlogEntry) UDate() string {
 // returns a list of them. Blog entries must have the '.md'
 // extension. Entries are searched in the directory recursively. If a
 // files is in a directory, the directory name is used as a prefix to
-// the blog entries name concatenated with a '-'. The blog is not
-// parsed or read. You should do that yourself elsewhere.
+// the blog entries name concatenated with a '-' and the top directory
+// is its Section. The blog is not parsed or read. You should do that
+// yourself elsewhere.
 // 返回 dir 文件夹下的 BlogEntry 的list,这些 Blog的文件必须是以 .md结尾. 如果Blog文件在一个子文件夹内，
 // 那么这个子文件夹的名字会作为Blog的前缀，并且以 "-" 来作为文件夹和BLOG文件的连接. 
 // BLOG 不会被解析和读取
@@ -154,9 +250,11 @@ func GetBlogFiles(dir string) ([]*BlogEntry, error) {
 				}
 
 				entries = append(entries, &BlogEntry{
-					Name: newName,
-					Url:  newName + ".html",
-					Path: blog.Path,
+					Name:    newName,
+					Url:     newName + ".html",
+					Path:    blog.Path,
+					Slug:    blog.Slug,
+					Section: file.Name(),
 				})
 
 			}
@@ -175,10 +273,16 @@ func GetBlogFiles(dir string) ([]*BlogEntry, error) {
 			// Just create the new entry.
 			// 生成新的BlogEntry，保存其文件名，带有新的扩展名html的URL和文件路径
 			// 将其加入到 entries里面
+			slug, err := MakeBlogName(newName)
+			if err != nil {
+				return nil, err
+			}
+
 			entries = append(entries, &BlogEntry{
 				Name: newName,
 				Url:  newName + ".html",
 				Path: p,
+				Slug: slug,
 			})
 		}
 	}
@@ -212,9 +316,51 @@ func MakeBlogName(names ...string) (string, error) {
 	return buf.String(), nil
 }
 
+// Slugify turns s into something that can be used in a url or a file
+// name. It is lower cased and everything other than letters and digits
+// becomes a single dash between them (e.g. "Hello, World!" becomes
+// "hello-world").
+// 将字符串转换成可以用于 url 或者文件名的形式
+func Slugify(s string) string {
+	buf := new(bytes.Buffer)
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			dash = true
+			continue
+		}
+
+		if dash && buf.Len() > 0 {
+			buf.WriteByte('-')
+		}
+		dash = false
+		buf.WriteRune(r)
+	}
+
+	return buf.String()
+}
+
+var (
+	// tagsRegexp matches the HTML tags PlainText removes.
+	tagsRegexp = regexp.MustCompile("<[^>]*>")
+
+	// spaceRegexp matches the runs of white space PlainText collapses.
+	spaceRegexp = regexp.MustCompile(`\s+`)
+)
+
+// PlainText returns the text of the given HTML (e.g. the Content of an
+// entry). The tags are removed, the entities are decoded and each run
+// of white space becomes a single space.
+// 去掉 HTML 标签，返回纯文本
+func PlainText(content string) string {
+	text := html.UnescapeString(tagsRegexp.ReplaceAllString(content, ""))
+	return strings.TrimSpace(spaceRegexp.ReplaceAllString(text, " "))
+}
+
 // gleanInfo is a helper function that searches for various comments
-// that contain useful information about the blog. It also uses fetchs
-// the update and create dates.
+// that contain useful information about the blog. The update and
+// create dates are only set if they are given in the comments, see
+// lookupTimes for the rest.
 // 这个函数主要作用就是用来收集blog的一些需要的信息。 
 // 这些信息是 md 文件中的一些诸如 Title, Author等等信息。在 md 文件中，我们通过类似 HTML中代码注释的写法
 // 来描述这片博客的内容，比如 <!--Title: This is a ttitle--> <!--Author:pyanfield-->
@@ -247,12 +393,115 @@ func (be *BlogEntry) gleanInfo(contents string) error {
 	if err != nil {
 		return err
 	}
-	// 获取文件的创建和修改时间，先去查找 git 的第一次提交和最后一次提交的日期
-	// 如果没有就去调用 Unix 的系统时间
-	be.Created, be.Updated, err = fs.GetTimes(be.Path)
+
+	be.Aliases, err = regexList("Aliases", contents)
+	if err != nil {
+		return err
+	}
+
+	enclosure, err := regexSingle("Enclosure", contents)
+	if err != nil {
+		return err
+	}
+	be.Enclosure, err = parseEnclosure(enclosure)
+	if err != nil {
+		return fmt.Errorf("Enclosure: %s", err)
+	}
+
+	slug, err := regexSingle("Slug", contents)
+	if err != nil {
+		return err
+	}
+	if slug != "" {
+		be.Slug = slug
+	}
+
+	sitemap, err := regexSingle("Sitemap", contents)
+	if err != nil {
+		return err
+	}
+	be.NoSitemap, err = parseSitemap(sitemap)
+	if err != nil {
+		return fmt.Errorf("Sitemap: %s", err)
+	}
+
+	draft, err := regexSingle("Draft", contents)
+	if err != nil {
+		return err
+	}
+	be.Draft, err = parseBool(draft)
+	if err != nil {
+		return fmt.Errorf("Draft: %s", err)
+	}
+
+	publish, err := regexSingle("PublishDate", contents)
+	if err != nil {
+		return err
+	}
+	be.PublishDate, err = parseTime(publish)
+	if err != nil {
+		return fmt.Errorf("PublishDate: %s", err)
+	}
+
+	expiry, err := regexSingle("ExpiryDate", contents)
+	if err != nil {
+		return err
+	}
+	be.ExpiryDate, err = parseTime(expiry)
+	if err != nil {
+		return fmt.Errorf("ExpiryDate: %s", err)
+	}
+
+	date, err := regexSingle("Date", contents)
+	if err != nil {
+		return err
+	}
+	be.Created, err = parseTime(date)
+	if err != nil {
+		return fmt.Errorf("Date: %s", err)
+	}
+
+	updated, err := regexSingle("Updated", contents)
 	if err != nil {
 		return err
 	}
+	be.Updated, err = parseTime(updated)
+	if err != nil {
+		return fmt.Errorf("Updated: %s", err)
+	}
+
+	// Everything else is kept as a custom parameter.
+	be.Params = regexParams(contents)
+
+	return nil
+}
+
+// lookupTimes fills in the Created and Updated dates that weren't
+// given in the metadata. Explicit dates always win; git (or the file
+// system) is only used as a fallback.
+// 如果元数据中没有给出 Date 或者 Updated，那么再去获取文件的创建和修改时间
+func (be *BlogEntry) lookupTimes() error {
+	if be.Created.IsZero() || be.Updated.IsZero() {
+		// 获取文件的创建和修改时间，先去查找 git 的第一次提交和最后一次提交的日期
+		// 如果没有就去调用 Unix 的系统时间
+		created, updated, err := fs.GetTimes(be.Path)
+		if err != nil {
+			return err
+		}
+
+		if be.Created.IsZero() {
+			be.Created = created
+		}
+
+		if be.Updated.IsZero() {
+			be.Updated = updated
+		}
+	}
+
+	// An imported entry may have a Date newer than its git history.
+	if be.Updated.Before(be.Created) {
+		be.Updated = be.Created
+	}
 
 	return nil
 }
@@ -274,6 +523,51 @@ func regexList(key, contents string) ([]string, error) {
 	return strings.Split(val, ","), nil
 }
 
+var (
+	// paramsRegexp matches a metadata comment (e.g. <!--Series: Go
+	// Basics-->). The value ends at the first -->, so two comments on
+	// a line are two values.
+	paramsRegexp = regexp.MustCompile("<!--[ ]*([A-Za-z][-A-Za-z0-9_]*):(.*?)-->")
+
+	// paramsLineRegexp matches a line of nothing but metadata comments.
+	paramsLineRegexp = regexp.MustCompile(
+		"^(?:<!--[ ]*[A-Za-z][-A-Za-z0-9_]*:.*?-->[ \t]*)+$")
+)
+
+// regexParams is a helper function that finds all of the metadata
+// comments whose keys are not one of the knownKeys. They are the lines
+// of comments at the top of the entry, so comments in the text (e.g.
+// <!-- TODO: fix -->) are left alone. The known keys are still found
+// anywhere by regexSingle, which older entries rely on. The first
+// value for each key wins, just like regexSingle.
+// 查找文件开头所有不属于 knownKeys 的注释元数据，作为自定义参数保存
+func regexParams(contents string) map[string]interface{} {
+	params := map[string]interface{}{}
+
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !paramsLineRegexp.MatchString(line) {
+			break
+		}
+
+		for _, matches := range paramsRegexp.FindAllStringSubmatch(line, -1) {
+			key := strings.ToLower(matches[1])
+			if knownKeys[key] {
+				continue
+			}
+
+			if _, ok := params[key]; !ok {
+				params[key] = strings.TrimSpace(matches[2])
+			}
+		}
+	}
+
+	return params
+}
+
 // regexSingle is a helper function that performs a regex search for
 // an HTML comment with the given title. It returns the value if it
 // was found, or "" if it wasn't.
@@ -0,0 +1,103 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package blogs
+
+import (
+	"fmt"
+	"mime"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Enclosure is a media file attached to a blog entry, like the audio
+// of a podcast episode. It comes from the enclosure metadata, which is
+// either the File or, in the front matter, a map of the values:
+//
+//	enclosure:
+//	  file: episodes/01.mp3
+//	  type: audio/mpeg
+//	  duration: "34:12"
+//	  episode: 1
+type Enclosure struct {
+	// File is the path of the file in the static directory (e.g.
+	// episodes/01.mp3), which is also its url from the root of the
+	// site.
+	File string
+
+	// Type is the MIME type of the file. It's guessed from the
+	// extension of the File when the metadata doesn't give it.
+	Type string
+
+	// Duration is how long the episode is (e.g. 34:12).
+	Duration string
+
+	// Episode is the number of the episode, or 0.
+	Episode int
+
+	// Length is the size of the file in bytes. It isn't in the
+	// metadata; the build fills it in from the file.
+	Length int64
+}
+
+// mediaTypes are the types of the usual podcast files. They don't
+// depend on the mime.types of the system like mime.TypeByExtension.
+var mediaTypes = map[string]string{
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/x-m4a",
+	".aac":  "audio/aac",
+	".ogg":  "audio/ogg",
+	".opus": "audio/opus",
+	".wav":  "audio/wav",
+	".mp4":  "video/mp4",
+	".m4v":  "video/x-m4v",
+	".mov":  "video/quicktime",
+}
+
+// parseEnclosure returns the Enclosure of the enclosure metadata.
+func parseEnclosure(v interface{}) (*Enclosure, error) {
+	e := &Enclosure{}
+
+	if m, ok := normalizeMeta(v).(map[string]interface{}); ok {
+		for k, v := range m {
+			switch strings.ToLower(k) {
+			case "file":
+				e.File = metaString(v)
+			case "type":
+				e.Type = metaString(v)
+			case "duration":
+				e.Duration = metaString(v)
+			case "episode":
+				n, err := strconv.Atoi(metaString(v))
+				if err != nil {
+					return nil, fmt.Errorf("episode: %s", err)
+				}
+				e.Episode = n
+			default:
+				return nil, fmt.Errorf("unknown value %s", k)
+			}
+		}
+	} else {
+		e.File = metaString(v)
+	}
+
+	e.File = strings.TrimPrefix(e.File, "/")
+	if e.File == "" {
+		return nil, nil
+	}
+
+	if e.Type == "" {
+		ext := strings.ToLower(path.Ext(e.File))
+		e.Type = mediaTypes[ext]
+		if e.Type == "" {
+			e.Type = mime.TypeByExtension(ext)
+		}
+		if e.Type == "" {
+			return nil, fmt.Errorf("no type for %s", e.File)
+		}
+	}
+
+	return e, nil
+}
@@ -0,0 +1,263 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package blogs
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+	"strings"
+)
+
+// These are the delimiters that open and close a front matter block
+// at the very top of a blog entry. "---" is used for YAML and "+++"
+// is used for TOML.
+const (
+	yamlDelimiter = "---"
+	tomlDelimiter = "+++"
+)
+
+// splitFrontMatter looks for a YAML or TOML front matter block at the
+// start of contents. If one is found, it is decoded into a map and the
+// remaining contents (without the block) are returned. If there is no
+// front matter, the map is nil and contents are returned untouched. A
+// block that is never closed or isn't a map is taken for markdown
+// (e.g. an entry starting with a --- horizontal rule), so it's left in
+// the contents too.
+// 如果 md 文件以 "---" (YAML) 或者 "+++" (TOML) 开头，那么解析这一段元数据，
+// 并返回去掉这一段之后的正文内容。
+func splitFrontMatter(contents []byte) (map[string]interface{}, []byte) {
+
+	// Ignore a byte order mark if the editor left one.
+	body := bytes.TrimPrefix(contents, []byte("\xef\xbb\xbf"))
+
+	// The first line decides what kind of front matter we have.
+	first, rest := splitLine(body)
+	delim := strings.TrimSpace(string(first))
+	if delim != yamlDelimiter && delim != tomlDelimiter {
+		return nil, contents
+	}
+
+	// Find the closing delimiter.
+	block := new(bytes.Buffer)
+	for len(rest) > 0 {
+		var line []byte
+		line, rest = splitLine(rest)
+		if strings.TrimSpace(string(line)) == delim {
+			meta, err := decodeFrontMatter(delim, block.Bytes())
+			if err != nil {
+				return nil, contents
+			}
+
+			return meta, rest
+		}
+
+		block.Write(line)
+		block.WriteByte('\n')
+	}
+
+	return nil, contents
+}
+
+// decodeFrontMatter decodes the given block using the format that
+// belongs to delim.
+func decodeFrontMatter(delim string, block []byte) (map[string]interface{},
+	error) {
+
+	meta := map[string]interface{}{}
+
+	switch delim {
+	case yamlDelimiter:
+		if err := yaml.Unmarshal(block, &meta); err != nil {
+			return nil, fmt.Errorf("parsing YAML front matter: %s", err)
+		}
+	case tomlDelimiter:
+		if _, err := toml.Decode(string(block), &meta); err != nil {
+			return nil, fmt.Errorf("parsing TOML front matter: %s", err)
+		}
+	}
+
+	// Keys are case insensitive, so store them all in lower case.
+	lower := make(map[string]interface{}, len(meta))
+	for k, v := range meta {
+		lower[strings.ToLower(k)] = v
+	}
+
+	return lower, nil
+}
+
+// splitLine returns the first line of b (without the line ending) and
+// everything after it.
+func splitLine(b []byte) ([]byte, []byte) {
+	i := bytes.IndexByte(b, '\n')
+	if i < 0 {
+		return bytes.TrimSuffix(b, []byte("\r")), nil
+	}
+
+	return bytes.TrimSuffix(b[:i], []byte("\r")), b[i+1:]
+}
+
+// applyFrontMatter copies the known keys of meta onto the BlogEntry.
+// Values from the front matter take precedence over the values found
+// in the HTML comments.
+func (be *BlogEntry) applyFrontMatter(meta map[string]interface{}) error {
+	var err error
+
+	if v, ok := meta["title"]; ok {
+		be.Title = metaString(v)
+	}
+
+	if v, ok := meta["author"]; ok {
+		be.Author = metaString(v)
+	}
+
+	if v, ok := meta["description"]; ok {
+		be.Description = metaString(v)
+	}
+
+	if v, ok := meta["tags"]; ok {
+		be.Tags = metaList(v)
+	}
+
+	if v, ok := meta["enclosure"]; ok {
+		be.Enclosure, err = parseEnclosure(v)
+		if err != nil {
+			return fmt.Errorf("enclosure: %s", err)
+		}
+	}
+
+	if v, ok := meta["aliases"]; ok {
+		be.Aliases = metaList(v)
+	}
+
+	if v, ok := meta["slug"]; ok && metaString(v) != "" {
+		be.Slug = metaString(v)
+	}
+
+	if v, ok := meta["languages"]; ok {
+		be.Languages = metaList(v)
+	}
+
+	if v, ok := meta["date"]; ok {
+		be.Created, err = metaTime(v)
+		if err != nil {
+			return fmt.Errorf("date: %s", err)
+		}
+	}
+
+	if v, ok := meta["updated"]; ok {
+		be.Updated, err = metaTime(v)
+		if err != nil {
+			return fmt.Errorf("updated: %s", err)
+		}
+	}
+
+	if v, ok := meta["sitemap"]; ok {
+		be.NoSitemap, err = parseSitemap(metaString(v))
+		if err != nil {
+			return fmt.Errorf("sitemap: %s", err)
+		}
+	}
+
+	if v, ok := meta["draft"]; ok {
+		be.Draft, err = metaBool(v)
+		if err != nil {
+			return fmt.Errorf("draft: %s", err)
+		}
+	}
+
+	if v, ok := meta["publishdate"]; ok {
+		be.PublishDate, err = metaTime(v)
+		if err != nil {
+			return fmt.Errorf("publishdate: %s", err)
+		}
+	}
+
+	if v, ok := meta["expirydate"]; ok {
+		be.ExpiryDate, err = metaTime(v)
+		if err != nil {
+			return fmt.Errorf("expirydate: %s", err)
+		}
+	}
+
+	// Anything we don't know about is a custom parameter.
+	if be.Params == nil {
+		be.Params = map[string]interface{}{}
+	}
+	for k, v := range meta {
+		if !knownKeys[k] {
+			be.Params[k] = normalizeMeta(v)
+		}
+	}
+
+	return nil
+}
+
+// normalizeMeta converts the nested maps that the YAML decoder returns
+// (map[interface{}]interface{}) into map[string]interface{} so they can
+// be used as .Params.key.subkey in the templates.
+func normalizeMeta(v interface{}) interface{} {
+	switch m := v.(type) {
+	case map[interface{}]interface{}:
+		n := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			n[fmt.Sprint(k)] = normalizeMeta(v)
+		}
+		return n
+	case map[string]interface{}:
+		n := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			n[k] = normalizeMeta(v)
+		}
+		return n
+	case []interface{}:
+		n := make([]interface{}, len(m))
+		for i, v := range m {
+			n[i] = normalizeMeta(v)
+		}
+		return n
+	}
+
+	return v
+}
+
+// metaString converts a front matter value into a string.
+func metaString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(fmt.Sprint(v))
+}
+
+// metaList converts a front matter value into a list of strings. Both
+// real lists and comma separated strings are accepted.
+func metaList(v interface{}) []string {
+	switch l := v.(type) {
+	case []interface{}:
+		list := make([]string, 0, len(l))
+		for _, item := range l {
+			list = append(list, metaString(item))
+		}
+		return list
+	case []string:
+		return l
+	case nil:
+		return []string{}
+	}
+
+	s := metaString(v)
+	if s == "" {
+		return []string{}
+	}
+
+	list := strings.Split(s, ",")
+	for i := range list {
+		list[i] = strings.TrimSpace(list[i])
+	}
+
+	return list
+}
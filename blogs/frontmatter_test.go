@@ -0,0 +1,195 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package blogs
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestSplitFrontMatter tests the splitFrontMatter function with YAML,
+// TOML and legacy entries.
+func TestSplitFrontMatter(t *testing.T) {
+	tests := []struct {
+		contents string
+		meta     map[string]interface{}
+		body     string
+	}{
+		// YAML front matter.
+		{
+			contents: "---\nTitle: Hello\ntags: [go, blog]\n---\n# Body\n",
+			meta: map[string]interface{}{
+				"title": "Hello",
+				"tags":  []interface{}{"go", "blog"},
+			},
+			body: "# Body\n",
+		},
+
+		// TOML front matter with windows line endings.
+		{
+			contents: "+++\r\ntitle = \"Hello\"\r\n+++\r\n# Body\r\n",
+			meta: map[string]interface{}{
+				"title": "Hello",
+			},
+			body: "# Body\r\n",
+		},
+
+		// Legacy comments only.
+		{
+			contents: "<!--Title: Hello-->\n# Body\n",
+			meta:     nil,
+			body:     "<!--Title: Hello-->\n# Body\n",
+		},
+
+		// A horizontal rule that is never closed is markdown.
+		{
+			contents: "---\nSome text.\n",
+			meta:     nil,
+			body:     "---\nSome text.\n",
+		},
+
+		// So are two horizontal rules around text that isn't a map.
+		{
+			contents: "---\nSome text.\n---\nMore text.\n",
+			meta:     nil,
+			body:     "---\nSome text.\n---\nMore text.\n",
+		},
+	}
+
+	for i, test := range tests {
+		meta, body := splitFrontMatter([]byte(test.contents))
+		if !reflect.DeepEqual(meta, test.meta) {
+			t.Errorf("(%d) expecting meta %v but got %v", i, test.meta, meta)
+		}
+
+		if string(body) != test.body {
+			t.Errorf("(%d) expecting body %q but got %q", i, test.body, body)
+		}
+	}
+}
+
+// TestApplyFrontMatter makes sure the front matter overrides the
+// values gleaned from the comments.
+func TestApplyFrontMatter(t *testing.T) {
+	be := &BlogEntry{
+		Title:  "Comment Title",
+		Author: "Comment Author",
+	}
+
+	be.applyFrontMatter(map[string]interface{}{
+		"title":     "Front Matter Title",
+		"tags":      "go, blog",
+		"languages": []interface{}{"go"},
+		"sitemap":   "exclude",
+	})
+
+	if be.Title != "Front Matter Title" {
+		t.Errorf("expecting front matter title but got %q", be.Title)
+	}
+
+	if be.Author != "Comment Author" {
+		t.Errorf("expecting comment author but got %q", be.Author)
+	}
+
+	if !reflect.DeepEqual(be.Tags, []string{"go", "blog"}) {
+		t.Errorf("expecting tags [go blog] but got %v", be.Tags)
+	}
+
+	if !reflect.DeepEqual(be.Languages, []string{"go"}) {
+		t.Errorf("expecting languages [go] but got %v", be.Languages)
+	}
+
+	if !be.NoSitemap {
+		t.Errorf("expecting the entry to be left out of the sitemap")
+	}
+}
+
+// TestParams makes sure unknown keys from both the comments and the
+// front matter end up in Params.
+func TestParams(t *testing.T) {
+	be := &BlogEntry{
+		Params: regexParams("<!--Title: Hi-->\n<!--Mood: happy-->\n" +
+			"<!--Series: Go-->\n<!--series: Ignored-->\n"),
+	}
+
+	be.applyFrontMatter(map[string]interface{}{
+		"hero": map[interface{}]interface{}{
+			"src": "/img/hero.png",
+		},
+	})
+
+	expected := map[string]interface{}{
+		"mood":   "happy",
+		"series": "Go",
+		"hero": map[string]interface{}{
+			"src": "/img/hero.png",
+		},
+	}
+
+	if !reflect.DeepEqual(be.Params, expected) {
+		t.Errorf("expecting params %v but got %v", expected, be.Params)
+	}
+}
+
+// TestParamsComments makes sure only the metadata comments at the top
+// of an entry are Params, each comment on its own.
+func TestParamsComments(t *testing.T) {
+	tests := []struct {
+		contents string
+		expected map[string]interface{}
+	}{
+		{"<!--Title: Hi--><!--Mood: happy-->\n<!--Series: Go-->\n",
+			map[string]interface{}{"mood": "happy", "series": "Go"}},
+		{"<!--Mood: happy-->\n\nSome text <!-- TODO: fix --> here.\n" +
+			"<!-- Note: later -->\n",
+			map[string]interface{}{"mood": "happy"}},
+		{"Some text.\n<!--Mood: happy-->\n", map[string]interface{}{}},
+		{"<!--Mood: happy--> and text\n", map[string]interface{}{}},
+	}
+
+	for i, test := range tests {
+		params := regexParams(test.contents)
+		if !reflect.DeepEqual(params, test.expected) {
+			t.Errorf("(%d) expecting %v but got %v", i, test.expected, params)
+		}
+	}
+}
+
+// TestParseEnclosure tests the enclosures from the comments and the
+// front matter.
+func TestParseEnclosure(t *testing.T) {
+	tests := []struct {
+		meta     interface{}
+		expected *Enclosure
+		err      bool
+	}{
+		{"/episodes/01.mp3", &Enclosure{File: "episodes/01.mp3",
+			Type: "audio/mpeg"}, false},
+		{map[interface{}]interface{}{
+			"file":     "episodes/02.m4a",
+			"duration": "34:12",
+			"episode":  2,
+		}, &Enclosure{File: "episodes/02.m4a", Type: "audio/x-m4a",
+			Duration: "34:12", Episode: 2}, false},
+		{map[string]interface{}{"file": "a.bin", "type": "audio/x-custom"},
+			&Enclosure{File: "a.bin", Type: "audio/x-custom"}, false},
+		{"", nil, false},
+		{"episodes/03.nope", nil, true},
+		{map[string]interface{}{"file": "a.mp3", "episode": "one"}, nil, true},
+		{map[string]interface{}{"file": "a.mp3", "size": 1}, nil, true},
+	}
+
+	for i, test := range tests {
+		e, err := parseEnclosure(test.meta)
+		if (err != nil) != test.err {
+			t.Errorf("(%d) expecting error %v but got %v", i, test.err, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(e, test.expected) {
+			t.Errorf("(%d) expecting %+v but got %+v", i, test.expected, e)
+		}
+	}
+}
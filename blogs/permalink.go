@@ -0,0 +1,92 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package blogs
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// DefaultPermalink is the permalink pattern used when the site doesn't
+// have one. It's the Name of the entry with .html at the root of the
+// site.
+const DefaultPermalink = "/:name.html"
+
+// tokenRegexp matches the tokens of a permalink pattern.
+var tokenRegexp = regexp.MustCompile(":[a-z]+")
+
+// Permalink returns the url of the entry made from the given pattern
+// (e.g. "/:year/:month/:slug/"). The entry must already be parsed. The
+// tokens are:
+//
+//      :year    - The year it was created (e.g. 2013).
+//      :month   - The month it was created (e.g. 04).
+//      :day     - The day it was created (e.g. 09).
+//      :slug    - The Slug of the entry.
+//      :name    - The Name of the entry.
+//      :section - The Section of the entry.
+//      :title   - The Title of the entry made usable in a url.
+//
+// Like every Url, the result doesn't start with a slash. Urls without
+// an extension are directories (e.g. 2013/04/hello/) whose page is
+// their index.html, see File. An empty pattern is DefaultPermalink.
+// 根据 pattern 生成博客的地址，比如 /:year/:month/:slug/
+func (be *BlogEntry) Permalink(pattern string) (string, error) {
+	if pattern == "" {
+		pattern = DefaultPermalink
+	}
+
+	var err error
+	url := tokenRegexp.ReplaceAllStringFunc(pattern, func(token string) string {
+		switch token {
+		case ":year":
+			return be.Created.Format("2006")
+		case ":month":
+			return be.Created.Format("01")
+		case ":day":
+			return be.Created.Format("02")
+		case ":slug":
+			return be.Slug
+		case ":name":
+			return be.Name
+		case ":section":
+			return be.Section
+		case ":title":
+			return Slugify(be.Title)
+		}
+
+		err = fmt.Errorf("unknown permalink token %s", token)
+		return token
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Empty tokens (like the :section of an entry at the top of the
+	// blog directory) leave double slashes behind.
+	url = strings.TrimPrefix(path.Clean("/"+url), "/")
+	if url == "" {
+		return "", fmt.Errorf("permalink %s is empty for %s", pattern, be.Path)
+	}
+
+	if path.Ext(url) == "" {
+		url += "/"
+	}
+
+	return url, nil
+}
+
+// File returns the file the entry is written to, relative to the
+// output directory. Urls that end with a slash are directories with an
+// index.html.
+func (be *BlogEntry) File() string {
+	if strings.HasSuffix(be.Url, "/") {
+		return be.Url + "index.html"
+	}
+
+	return be.Url
+}
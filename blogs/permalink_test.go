@@ -0,0 +1,63 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package blogs
+
+import (
+	"testing"
+	"time"
+)
+
+// TestPermalink tests the urls and files made from permalink patterns.
+func TestPermalink(t *testing.T) {
+	be := &BlogEntry{
+		Name:    "golang-intro",
+		Title:   "An Intro to Go",
+		Slug:    "intro",
+		Section: "golang",
+		Created: time.Date(2013, 4, 9, 12, 0, 0, 0, time.UTC),
+	}
+	top := &BlogEntry{Name: "hello", Slug: "hello"}
+
+	tests := []struct {
+		be        *BlogEntry
+		pattern   string
+		url, file string
+	}{
+		{be, "", "golang-intro.html", "golang-intro.html"},
+		{be, "/:year/:month/:slug/", "2013/04/intro/",
+			"2013/04/intro/index.html"},
+		{be, "/:year/:month/:day/:title", "2013/04/09/an-intro-to-go/",
+			"2013/04/09/an-intro-to-go/index.html"},
+		{be, "/posts/:section/:slug.html", "posts/golang/intro.html",
+			"posts/golang/intro.html"},
+		{top, "/posts/:section/:slug.html", "posts/hello.html",
+			"posts/hello.html"},
+	}
+
+	for i, test := range tests {
+		url, err := test.be.Permalink(test.pattern)
+		if err != nil {
+			t.Errorf("(%d) unexpected error: %s", i, err)
+			continue
+		}
+
+		if url != test.url {
+			t.Errorf("(%d) expecting url '%s' but got '%s'", i, test.url, url)
+		}
+
+		e := &BlogEntry{Url: url}
+		if e.File() != test.file {
+			t.Errorf("(%d) expecting file '%s' but got '%s'", i, test.file,
+				e.File())
+		}
+	}
+
+	// Unknown tokens and empty urls are errors.
+	for i, pattern := range []string{"/:category/:slug/", "/:section/"} {
+		if _, err := top.Permalink(pattern); err == nil {
+			t.Errorf("(%d) expecting an error for %s", i, pattern)
+		}
+	}
+}
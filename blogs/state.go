@@ -0,0 +1,169 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package blogs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// These are the states a blog entry can be in. Only published entries
+// are rendered unless the build asks for the others.
+const (
+	StatePublished = "published"
+	StateDraft     = "draft"
+	StateScheduled = "scheduled"
+	StateExpired   = "expired"
+)
+
+// timeFormats are the layouts that are tried, in order, when parsing
+// a date from the metadata. Layouts without a time zone are read in
+// the local time zone.
+var timeFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04 -0700",
+	"2006-01-02 15:04 MST",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006/01/02",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC822Z,
+	time.RFC822,
+	"January 2, 2006 15:04",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+}
+
+// State returns the state of the blog entry at the given time. A draft
+// is always a draft, otherwise the PublishDate and ExpiryDate decide
+// whether the entry is scheduled, expired or published.
+// 返回博客在 now 这个时间的状态：草稿，定时发布，已过期或者已发布
+func (be *BlogEntry) State(now time.Time) string {
+	switch {
+	case be.Draft:
+		return StateDraft
+	case !be.PublishDate.IsZero() && now.Before(be.PublishDate):
+		return StateScheduled
+	case !be.ExpiryDate.IsZero() && !now.Before(be.ExpiryDate):
+		return StateExpired
+	}
+
+	return StatePublished
+}
+
+// Visible returns true if the entry should be published at the given
+// time. Drafts, scheduled and expired entries are only visible when
+// asked for.
+func (be *BlogEntry) Visible(now time.Time, drafts, future,
+	expired bool) bool {
+
+	switch be.State(now) {
+	case StateDraft:
+		return drafts
+	case StateScheduled:
+		return future
+	case StateExpired:
+		return expired
+	}
+
+	return true
+}
+
+// Filter returns the entries that should be published right now. The
+// drafts, future and expired flags include drafts, scheduled entries
+// and expired entries respectively. The entries must already be
+// parsed. Everything that lists entries (the index, the archives, the
+// tags and the feeds) should be given the filtered entries so the
+// hidden ones don't show up.
+// 过滤掉不应该发布的博客，比如草稿，还没有到发布时间的和已经过期的博客
+func Filter(entries []*BlogEntry, drafts, future,
+	expired bool) []*BlogEntry {
+
+	now := time.Now()
+	visible := make([]*BlogEntry, 0, len(entries))
+
+	for _, be := range entries {
+		if be.Visible(now, drafts, future, expired) {
+			visible = append(visible, be)
+		}
+	}
+
+	return visible
+}
+
+// parseTime parses a date from the metadata using the timeFormats. It
+// returns the zero time for an empty string.
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	for _, format := range timeFormats {
+		t, err := time.ParseInLocation(format, s, time.Local)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unknown date format: %q", s)
+}
+
+// parseBool parses a boolean from the metadata. An empty string is
+// false.
+func parseBool(s string) (bool, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return false, nil
+	}
+
+	return strconv.ParseBool(s)
+}
+
+// parseSitemap parses the Sitemap metadata. It returns true when the
+// entry is left out of the sitemap: "exclude". An empty string and
+// "include" keep it in.
+func parseSitemap(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "include":
+		return false, nil
+	case "exclude":
+		return true, nil
+	}
+
+	return false, fmt.Errorf("unknown value %s", s)
+}
+
+// metaTime converts a front matter value into a time. TOML decodes
+// dates itself while YAML leaves them as strings.
+func metaTime(v interface{}) (time.Time, error) {
+	if t, ok := v.(time.Time); ok {
+		return t, nil
+	}
+
+	return parseTime(metaString(v))
+}
+
+// metaBool converts a front matter value into a boolean.
+func metaBool(v interface{}) (bool, error) {
+	if b, ok := v.(bool); ok {
+		return b, nil
+	}
+
+	return parseBool(metaString(v))
+}
@@ -0,0 +1,91 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package blogs
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFilter tests the State method through the Filter function.
+func TestFilter(t *testing.T) {
+	now := time.Now()
+	published := &BlogEntry{Name: "published"}
+	draft := &BlogEntry{Name: "draft", Draft: true}
+	scheduled := &BlogEntry{Name: "scheduled",
+		PublishDate: now.Add(time.Hour)}
+	expired := &BlogEntry{Name: "expired",
+		ExpiryDate: now.Add(-time.Hour)}
+	all := []*BlogEntry{published, draft, scheduled, expired}
+
+	tests := []struct {
+		drafts, future, expired bool
+		expected                []*BlogEntry
+	}{
+		{expected: []*BlogEntry{published}},
+		{drafts: true, expected: []*BlogEntry{published, draft}},
+		{future: true, expected: []*BlogEntry{published, scheduled}},
+		{expired: true, expected: []*BlogEntry{published, expired}},
+		{drafts: true, future: true, expired: true, expected: all},
+	}
+
+	for i, test := range tests {
+		result := Filter(all, test.drafts, test.future, test.expired)
+		if len(result) != len(test.expected) {
+			t.Errorf("(%d) expecting %d entries but got %d", i,
+				len(test.expected), len(result))
+			continue
+		}
+
+		for k, v := range test.expected {
+			if result[k] != v {
+				t.Errorf("(%d) expecting '%s' at %d but got '%s'", i,
+					v.Name, k, result[k].Name)
+			}
+		}
+	}
+}
+
+// TestParseTime tests parseTime with a few of the timeFormats.
+func TestParseTime(t *testing.T) {
+	utc := time.Date(2013, time.April, 5, 14, 30, 0, 0, time.UTC)
+	local := time.Date(2013, time.April, 5, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		value    string
+		expected time.Time
+		err      bool
+	}{
+		{value: "2013-04-05T14:30:00Z", expected: utc},
+		{value: "2013-04-05T16:30:00+02:00", expected: utc},
+		{value: "2013-04-05 09:30:00 -0500", expected: utc},
+		{value: "2013-04-05 14:30 +0000", expected: utc},
+		{value: "Fri, 05 Apr 2013 14:30:00 +0000", expected: utc},
+		{value: "2013-04-05", expected: local},
+		{value: "April 5, 2013", expected: local},
+		{value: "", expected: time.Time{}},
+		{value: "yesterday", err: true},
+	}
+
+	for i, test := range tests {
+		result, err := parseTime(test.value)
+		if test.err {
+			if err == nil {
+				t.Errorf("(%d) expecting an error for %q", i, test.value)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("(%d) unexpected error for %q: %s", i, test.value, err)
+			continue
+		}
+
+		if !result.Equal(test.expected) {
+			t.Errorf("(%d) expecting %s for %q but got %s", i,
+				test.expected, test.value, result)
+		}
+	}
+}
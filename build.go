@@ -0,0 +1,862 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pyanfield/goblog/aliases"
+	"github.com/pyanfield/goblog/archives"
+	"github.com/pyanfield/goblog/blogs"
+	"github.com/pyanfield/goblog/config"
+	"github.com/pyanfield/goblog/fs"
+	"github.com/pyanfield/goblog/manifest"
+	"github.com/pyanfield/goblog/pages"
+	"github.com/pyanfield/goblog/paginator"
+	"github.com/pyanfield/goblog/rss"
+	"github.com/pyanfield/goblog/sitemap"
+	"github.com/pyanfield/goblog/tags"
+	"github.com/pyanfield/goblog/templates"
+	iofs "io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BuildErrors is a list of errors collected during a build. All of
+// them are reported together instead of stopping at the first one.
+type BuildErrors []error
+
+// Error returns every error, one per line.
+func (be BuildErrors) Error() string {
+	lines := make([]string, 0, len(be))
+	for _, err := range be {
+		lines = append(lines, err.Error())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// forEach calls fn for every i in [0, n) using at most jobs goroutines
+// at a time. It waits for all of them to finish and returns a
+// BuildErrors with every error in order of i, or nil if there weren't
+// any.
+// 使用最多 jobs 个 goroutine 并发的执行 fn，收集所有的错误一起返回
+func forEach(n, jobs int, fn func(i int) error) error {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	errs := make([]error, n)
+	work := make(chan int)
+	wg := new(sync.WaitGroup)
+
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				errs[i] = fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	// Only keep the real errors.
+	var result BuildErrors
+	for _, err := range errs {
+		if err != nil {
+			result = append(result, err)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+
+	return result
+}
+
+// loadManifest returns the Manifest of the previous build in the
+// OutputDir, or an empty one if the build is forced.
+func loadManifest() (*manifest.Manifest, error) {
+	file := path.Join(OutputDir, manifest.FileName)
+	if Force {
+		return manifest.New(file), nil
+	}
+
+	return manifest.Load(file)
+}
+
+// render calls gen to generate the given file (relative to the
+// OutputDir) unless it was generated from the same inputs, as given by
+// hash, in the previous build and still exists.
+// 如果 file 的输入内容与上次生成时相同，并且文件还在，那么就跳过，否则调用 gen 重新生成
+func render(m *manifest.Manifest, file, hash string, gen func() error) error {
+	_, err := os.Stat(path.Join(OutputDir, file))
+	if err == nil && !m.Changed(file, hash) {
+		m.Set(file, hash)
+		return nil
+	}
+
+	err = gen()
+	if err != nil {
+		return err
+	}
+
+	m.Set(file, hash)
+	return nil
+}
+
+// removeStale deletes the files that the previous build generated but
+// this one didn't, like the pages of entries that were removed. The
+// directories they leave empty (e.g. page/3/) are removed too.
+func removeStale(m *manifest.Manifest) error {
+	for _, file := range m.Stale() {
+		err := os.Remove(path.Join(OutputDir, file))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		// Remove fails on directories that aren't empty.
+		for dir := path.Dir(file); dir != "."; dir = path.Dir(dir) {
+			if os.Remove(path.Join(OutputDir, dir)) != nil {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// buildSite generates the whole site. It is split into two stages. The
+// parse stage reads every blog entry and standalone page exactly once.
+// The render stage then writes every page. Both stages use Jobs
+// goroutines. Pages and static files whose inputs haven't changed
+// since the last build are left alone unless Force is set.
+// 生成整个站点。先并发的解析所有的博客，然后再并发的生成所有的页面。
+func buildSite() error {
+	// The git history may have changed since the last build.
+	fs.ResetGitHistories()
+
+	// Find out what the last build did.
+	m, err := loadManifest()
+	if err != nil {
+		return fmt.Errorf("loading build manifest: %s", err)
+	}
+
+	// Now, move the static files over, the site's own over the
+	// theme's. Only the ones that changed are copied.
+	// 复制主题和 static 文件夹下所有的子文件夹和子文件到 public 文件夹下
+	staticFiles, err := themeFiles(StaticDir, "static")
+	if err != nil {
+		return fmt.Errorf("making output dir: %s", err)
+	}
+	err = fs.CopyFS(OutputDir, staticFiles, func(d string, contents []byte) (bool, error) {
+		hash := manifest.HashStrings(string(contents))
+
+		file := strings.TrimPrefix(d, OutputDir+"/")
+		_, statErr := os.Stat(d)
+		changed := statErr != nil || m.Changed(file, hash)
+		m.Set(file, hash)
+		return changed, nil
+	})
+	if err != nil {
+		return fmt.Errorf("making output dir: %s", err)
+	}
+
+	// Get a list of files from the BlogDir.
+	// 得到Blog文件夹下的所有md文件列表，如果在Blog下有子文件夹，那么这个文件夹的名字作为前缀，以"-"为连接符，形成新的文件名
+	entries, err := blogs.GetBlogFiles(BlogDir)
+	if err != nil {
+		return fmt.Errorf("getting blog file list: %s", err)
+	}
+
+	// The parse stage. Each entry is parsed for it's useful data and
+	// its HTML is cached on the entry. Then its url is made from the
+	// site's permalink pattern.
+	// 解析 md 文件的内容，并且获取一些描述信息，比如 title, author ,date等等
+	err = forEach(len(entries), Jobs, func(i int) error {
+		_, err := entries[i].Parse()
+		if err != nil {
+			return fmt.Errorf("parsing blog %s: %s", entries[i].Path, err)
+		}
+
+		entries[i].Url, err = entries[i].Permalink(Site.Permalink)
+		if err != nil {
+			return fmt.Errorf("parsing blog %s: %s", entries[i].Path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Drop the drafts, scheduled and expired entries unless we were
+	// asked to render them. Everything below only sees what's left.
+	// 过滤掉草稿，定时发布和已经过期的博客
+	entries = blogs.Filter(entries, BuildDrafts, BuildFuture, BuildExpired)
+
+	// The podcast feed gives the size of each enclosure, which is
+	// one of the static files.
+	podcast := false
+	for _, blog := range entries {
+		if blog.Enclosure == nil {
+			continue
+		}
+
+		info, err := iofs.Stat(staticFiles, blog.Enclosure.File)
+		if err != nil {
+			return fmt.Errorf("blog %s: enclosure: %s", blog.Path, err)
+		}
+		blog.Enclosure.Length = info.Size()
+		podcast = true
+	}
+
+	// The standalone pages are parsed and filtered the same way.
+	// 解析并过滤 pages 文件夹下的独立页面
+	pageList, err := pages.GetPages(PageDir)
+	if err != nil {
+		return fmt.Errorf("getting page list: %s", err)
+	}
+	err = forEach(len(pageList), Jobs, func(i int) error {
+		err := pageList[i].Parse()
+		if err != nil {
+			return fmt.Errorf("parsing page %s: %s", pageList[i].Path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	pageList = pages.Filter(pageList, BuildDrafts, BuildFuture, BuildExpired)
+
+	// The pages may add themselves to the menus.
+	site := siteWithPages(pageList)
+
+	// Nothing can be written to the same file as something else. The
+	// tasks run at the same time, so one would silently replace the
+	// other.
+	files, err := outputFiles(entries, pageList, site, staticFiles)
+	if err != nil {
+		return err
+	}
+
+	// The entries and pages may have old urls that redirect to them.
+	// They can't be where anything else is written either.
+	aliasList, err := collectAliases(entries, pageList, files)
+	if err != nil {
+		return err
+	}
+	for _, format := range site.Redirects {
+		if _, ok := aliases.Formats[format]; !ok {
+			return fmt.Errorf("redirects: unknown format %s", format)
+		}
+	}
+	if !rss.ValidContent(site.FeedContent) {
+		return fmt.Errorf("feed_content: unknown value %s", site.FeedContent)
+	}
+
+	// Now load the templates. The site's own templates override the
+	// theme's.
+	tmpltFiles, err := themeFiles(TemplateDir, "templates")
+	if err != nil {
+		return fmt.Errorf("loading templates: %s", err)
+	}
+	tmplts, err := templates.LoadTemplates(tmpltFiles, site)
+	if err != nil {
+		return fmt.Errorf("loading templates: %s", err)
+	}
+
+	// The feeds and the sitemap need the full url of the site. Without
+	// one, the <link> of channel.rss is used, and without that they are
+	// left out. The pages don't need it.
+	siteURL := URL
+	if !rss.ValidLink(siteURL) {
+		siteURL = rss.ChannelLink(tmpltFiles)
+	}
+	feeds := rss.ValidLink(siteURL)
+	if !feeds {
+		fmt.Printf("url: %q isn't a full url, so there are no feeds or sitemap\n",
+			URL)
+		tmplts.Feeds = nil
+	}
+
+	// Every page depends on the templates and the settings.
+	tmpltsHash, err := manifest.HashFS(tmpltFiles)
+	if err != nil {
+		return fmt.Errorf("hashing templates: %s", err)
+	}
+	siteConfig, err := json.Marshal(site)
+	if err != nil {
+		return fmt.Errorf("hashing configuration: %s", err)
+	}
+	settingsHash := manifest.HashStrings(string(siteConfig), URL,
+		strconv.Itoa(MaxIndexEntries), strconv.FormatBool(BuildDrafts),
+		strconv.FormatBool(BuildFuture), strconv.FormatBool(BuildExpired))
+
+	// Hash the inputs of each entry. The listing pages depend on all of
+	// them and on the date (the pages show when they were created).
+	entryHashes := make(map[*blogs.BlogEntry]string, len(entries))
+	allHashes := []string{tmpltsHash, settingsHash,
+		time.Now().Format("2006-01-02")}
+	for _, blog := range entries {
+		source, err := manifest.HashFile(blog.Path)
+		if err != nil {
+			return fmt.Errorf("hashing blog %s: %s", blog.Path, err)
+		}
+
+		hash := manifest.HashStrings(source, tmpltsHash, settingsHash,
+			blog.Created.String(), blog.Updated.String())
+		if blog.Enclosure != nil {
+			hash = manifest.HashStrings(hash,
+				strconv.FormatInt(blog.Enclosure.Length, 10))
+		}
+		entryHashes[blog] = hash
+		allHashes = append(allHashes, blog.Path+" "+hash)
+	}
+	siteHash := manifest.HashStrings(allHashes...)
+
+	// Get a sorted list of tags.
+	t := tags.ParseBlogs(entries).Slice()
+
+	// Get a sort list of archives.
+	a := archives.ParseBlogs(entries).Slice()
+
+	// Every feed has the feed_entries most recent entries.
+	channel := rss.NewChannel(site, siteURL)
+	feedCount := site.FeedEntries
+	if feedCount < 1 {
+		feedCount = 10
+	}
+	feedEntries := archives.GetMostRecent(a, feedCount)
+
+	// The render stage. Every page is a task. The urls of the pages are
+	// gathered for the sitemap as they are added.
+	urls := []sitemap.URL{
+		sitemap.New("about.html"),
+		sitemap.New("tags.html", entries...),
+	}
+	tasks := []func() error{
+		func() error {
+			// Generate the about page.
+			err := render(m, "about.html", siteHash, func() error {
+				return tmplts.MakeAbout(OutputDir)
+			})
+			if err != nil {
+				return fmt.Errorf("generating about.html: %s", err)
+			}
+			return nil
+		},
+		func() error {
+			// Generate the tags page.
+			err := render(m, "tags.html", siteHash, func() error {
+				return tmplts.MakeTags(OutputDir, t)
+			})
+			if err != nil {
+				return fmt.Errorf("generating tags.html: %s", err)
+			}
+			return nil
+		},
+	}
+
+	// The feeds need the url of the site.
+	if feeds {
+		tasks = append(tasks, func() error {
+			// Generate the RSS feed.
+			err := render(m, "feed.rss", siteHash, func() error {
+				return rss.MakeRss(feedEntries, channel, tmpltFiles, OutputDir)
+			})
+			if err != nil {
+				return fmt.Errorf("generating feed.rss: %s", err)
+			}
+			return nil
+		}, func() error {
+			err := render(m, "atom.xml", siteHash, func() error {
+				return rss.MakeAtom(feedEntries, channel, OutputDir)
+			})
+			if err != nil {
+				return fmt.Errorf("generating atom.xml: %s", err)
+			}
+			return nil
+		}, func() error {
+			err := render(m, "feed.json", siteHash, func() error {
+				return rss.MakeJSONFeed(feedEntries, channel, OutputDir)
+			})
+			if err != nil {
+				return fmt.Errorf("generating feed.json: %s", err)
+			}
+			return nil
+		})
+	}
+
+	// The podcast feed has every episode, not just the most recent
+	// ones, if there are any.
+	if feeds && podcast {
+		episodes := archives.GetMostRecent(a, len(entries))
+		tasks = append(tasks, func() error {
+			err := render(m, "podcast.rss", siteHash, func() error {
+				return rss.MakePodcast(episodes, channel, site.Podcast,
+					OutputDir)
+			})
+			if err != nil {
+				return fmt.Errorf("generating podcast.rss: %s", err)
+			}
+			return nil
+		})
+	}
+
+	// Generate each page of the index and the archives. The entries
+	// are newest first.
+	// 分页生成首页和归档页面
+	recent := archives.GetMostRecent(a, len(entries))
+	for _, p := range paginator.Paginate(len(recent), MaxIndexEntries, "/", "/") {
+		p := p
+		urls = append(urls, sitemap.New(p.Url, recent[p.Start:p.End]...))
+		tasks = append(tasks, func() error {
+			err := render(m, p.File(), siteHash, func() error {
+				return tmplts.MakeIndex(OutputDir, recent[p.Start:p.End], p)
+			})
+			if err != nil {
+				return fmt.Errorf("generating %s: %s", p.File(), err)
+			}
+			return nil
+		})
+	}
+	for _, p := range paginator.Paginate(len(recent), site.ArchiveEntries,
+		"/archives.html", "/archives/") {
+		p := p
+		urls = append(urls, sitemap.New(p.Url, recent[p.Start:p.End]...))
+		tasks = append(tasks, func() error {
+			err := render(m, p.File(), siteHash, func() error {
+				years := archives.ParseBlogs(recent[p.Start:p.End]).Slice()
+				return tmplts.MakeArchive(OutputDir, years, p)
+			})
+			if err != nil {
+				return fmt.Errorf("generating %s: %s", p.File(), err)
+			}
+			return nil
+		})
+	}
+
+	// Generate a page for each year and month with entries. They are
+	// newest first, so the previous (older) one comes after each.
+	// 为每一年和每个月生成归档页面
+	for i, y := range a {
+		var prev, next *archives.YearEntries
+		if i > 0 {
+			next = a[i-1]
+		}
+		if i < len(a)-1 {
+			prev = a[i+1]
+		}
+
+		y := y
+		urls = append(urls, sitemap.New(y.Url,
+			archives.GetMostRecent([]*archives.YearEntries{y}, len(entries))...))
+		tasks = append(tasks, func() error {
+			file := y.Url + "index.html"
+			err := render(m, file, siteHash, func() error {
+				return tmplts.MakeYear(OutputDir, y, prev, next)
+			})
+			if err != nil {
+				return fmt.Errorf("generating %s: %s", file, err)
+			}
+			return nil
+		})
+	}
+	months := a.Months()
+	for i, month := range months {
+		var prev, next *archives.MonthEntries
+		if i > 0 {
+			next = months[i-1]
+		}
+		if i < len(months)-1 {
+			prev = months[i+1]
+		}
+
+		month := month
+		urls = append(urls, sitemap.New(month.Url, month.Entries...))
+		tasks = append(tasks, func() error {
+			file := month.Url + "index.html"
+			err := render(m, file, siteHash, func() error {
+				return tmplts.MakeMonth(OutputDir, month, prev, next)
+			})
+			if err != nil {
+				return fmt.Errorf("generating %s: %s", file, err)
+			}
+			return nil
+		})
+	}
+
+	// Generate the pages and the feed of each tag. Like the main feed,
+	// a tag's feed has its feed_entries most recent entries.
+	// 为每个标签生成页面和 feed.rss
+	for _, tag := range t {
+		tag := tag
+		for _, p := range paginator.Paginate(len(tag.Entries), site.TagEntries,
+			"/"+tag.Url, "/"+tag.Url) {
+			p := p
+			urls = append(urls, sitemap.New(p.Url,
+				tag.Entries[p.Start:p.End]...))
+			tasks = append(tasks, func() error {
+				err := render(m, p.File(), siteHash, func() error {
+					return tmplts.MakeTag(OutputDir, tag,
+						tag.Entries[p.Start:p.End], p)
+				})
+				if err != nil {
+					return fmt.Errorf("generating %s: %s", p.File(), err)
+				}
+				return nil
+			})
+		}
+
+		if !feeds {
+			continue
+		}
+		tasks = append(tasks, func() error {
+			file := tag.Url + "feed.rss"
+			err := render(m, file, siteHash, func() error {
+				dir := path.Join(OutputDir, tag.Url)
+				err := os.MkdirAll(dir, 0750)
+				if err != nil {
+					return err
+				}
+
+				recent := tag.Entries
+				if len(recent) > feedCount {
+					recent = recent[:feedCount]
+				}
+
+				// The feed is named after the tag.
+				ch := channel
+				ch.Tag, ch.TagUrl = tag.Name, tag.Url
+				return rss.MakeRss(recent, ch, tmpltFiles, dir)
+			})
+			if err != nil {
+				return fmt.Errorf("generating %s: %s", file, err)
+			}
+			return nil
+		})
+	}
+
+	// Generate each standalone page.
+	for _, page := range pageList {
+		page := page
+		if !page.NoSitemap {
+			urls = append(urls, sitemap.New(page.Url, page.BlogEntry))
+		}
+		tasks = append(tasks, func() error {
+			source, err := manifest.HashFile(page.Path)
+			if err != nil {
+				return fmt.Errorf("hashing page %s: %s", page.Path, err)
+			}
+			hash := manifest.HashStrings(source, tmpltsHash, settingsHash,
+				page.Created.String(), page.Updated.String())
+
+			err = render(m, page.File(), hash, func() error {
+				return tmplts.MakePage(OutputDir, page)
+			})
+			if err != nil {
+				return fmt.Errorf("generating page %s: %s", page.Path, err)
+			}
+			return nil
+		})
+	}
+
+	// Generate a redirect page for each alias and the redirect files
+	// for the web servers. They only depend on the urls.
+	// 为每个别名生成跳转页面，以及 web 服务器使用的跳转配置文件
+	aliasHashes := []string{URL}
+	for _, a := range aliasList {
+		a := a
+		aliasHashes = append(aliasHashes, a.From+" "+a.To)
+		tasks = append(tasks, func() error {
+			err := render(m, a.File(), manifest.HashStrings(URL, a.To), func() error {
+				return aliases.MakePage(OutputDir, a, URL)
+			})
+			if err != nil {
+				return fmt.Errorf("generating alias %s: %s", a.File(), err)
+			}
+			return nil
+		})
+	}
+	for _, format := range site.Redirects {
+		format := format
+		tasks = append(tasks, func() error {
+			file := aliases.Formats[format]
+			err := render(m, file, manifest.HashStrings(aliasHashes...), func() error {
+				return aliases.MakeRedirects(OutputDir, format, aliasList, URL)
+			})
+			if err != nil {
+				return fmt.Errorf("generating %s: %s", file, err)
+			}
+			return nil
+		})
+	}
+
+	// Generate a page for each blog.
+	for _, blog := range entries {
+		blog := blog
+		if !blog.NoSitemap {
+			urls = append(urls, sitemap.New(blog.Url, blog))
+		}
+		tasks = append(tasks, func() error {
+			err := render(m, blog.File(), entryHashes[blog], func() error {
+				return tmplts.MakeBlogEntry(OutputDir, blog)
+			})
+			if err != nil {
+				return fmt.Errorf("generating blog html %s: %s", blog.Path, err)
+			}
+			return nil
+		})
+	}
+
+	// The sitemap and robots.txt need the url of the site too.
+	if feeds {
+		// Generate the sitemap of every page above except the redirects
+		// and the entries and pages that leave themselves out. It's split
+		// up behind a sitemap index when it's too big for one file.
+		// 生成 sitemap.xml，页面太多时生成多个 sitemap 和一个索引
+		parts, err := sitemap.Split(siteURL, urls)
+		if err != nil {
+			return fmt.Errorf("generating %s: %s", sitemap.File, err)
+		}
+		partHashes := []string{}
+		for i, part := range parts {
+			file := sitemap.File
+			if len(parts) > 1 {
+				file = sitemap.PartFile(i)
+			}
+
+			locs := []string{siteURL}
+			for _, u := range part {
+				locs = append(locs, u.Loc+" "+u.LastMod.String())
+			}
+			hash := manifest.HashStrings(locs...)
+			partHashes = append(partHashes, hash)
+
+			part := part
+			tasks = append(tasks, func() error {
+				err := render(m, file, hash, func() error {
+					return sitemap.Make(OutputDir, file, siteURL, part)
+				})
+				if err != nil {
+					return fmt.Errorf("generating %s: %s", file, err)
+				}
+				return nil
+			})
+		}
+		if len(parts) > 1 {
+			tasks = append(tasks, func() error {
+				hash := manifest.HashStrings(append(partHashes, siteURL)...)
+				err := render(m, sitemap.File, hash, func() error {
+					return sitemap.MakeIndex(OutputDir, siteURL, parts)
+				})
+				if err != nil {
+					return fmt.Errorf("generating %s: %s", sitemap.File, err)
+				}
+				return nil
+			})
+		}
+
+		// The robots.txt points at the sitemap, unless the site has its
+		// own in its static files.
+		if _, err := iofs.Stat(staticFiles, "robots.txt"); err != nil {
+			tasks = append(tasks, func() error {
+				err := render(m, "robots.txt", manifest.HashStrings(siteURL), func() error {
+					return sitemap.MakeRobots(OutputDir, siteURL)
+				})
+				if err != nil {
+					return fmt.Errorf("generating robots.txt: %s", err)
+				}
+				return nil
+			})
+		}
+	}
+
+	err = forEach(len(tasks), Jobs, func(i int) error {
+		return tasks[i]()
+	})
+	if err != nil {
+		// Keep the old manifest so the next build tries again.
+		return err
+	}
+
+	// Clean up after entries and static files that went away and
+	// remember what this build did.
+	err = removeStale(m)
+	if err != nil {
+		return fmt.Errorf("removing stale files: %s", err)
+	}
+
+	err = m.Save()
+	if err != nil {
+		return fmt.Errorf("saving build manifest: %s", err)
+	}
+
+	return nil
+}
+
+// generatedFiles returns the files the build writes besides the
+// entries, pages and aliases, and what writes each of them: the static
+// files, the listings of the given entries, the feeds, the sitemap and
+// the redirect files.
+func generatedFiles(entries []*blogs.BlogEntry, list []*pages.Page,
+	site *config.Config, static iofs.FS) (map[string]string, error) {
+
+	files := map[string]string{
+		"about.html":      "the about page",
+		"tags.html":       "the tags page",
+		"feed.rss":        "the RSS feed",
+		"atom.xml":        "the Atom feed",
+		"feed.json":       "the JSON feed",
+		"podcast.rss":     "the podcast feed",
+		sitemap.File:      "the sitemap",
+		"robots.txt":      "robots.txt",
+		manifest.FileName: "the build manifest",
+	}
+	for format, file := range aliases.Formats {
+		files[file] = "the " + format + " redirects"
+	}
+
+	err := iofs.WalkDir(static, ".", func(p string, d iofs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files[p] = "the static file " + p
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range paginator.Paginate(len(entries), MaxIndexEntries, "/", "/") {
+		files[p.File()] = "the index"
+	}
+	for _, p := range paginator.Paginate(len(entries), site.ArchiveEntries,
+		"/archives.html", "/archives/") {
+		files[p.File()] = "the archives"
+	}
+
+	a := archives.ParseBlogs(entries).Slice()
+	for _, y := range a {
+		files[y.Url+"index.html"] = "the archive of " + y.Year
+	}
+	for _, m := range a.Months() {
+		files[m.Url+"index.html"] = "the archive of " + m.Month + " " + m.Year
+	}
+
+	for _, tag := range tags.ParseBlogs(entries).Slice() {
+		for _, p := range paginator.Paginate(len(tag.Entries), site.TagEntries,
+			"/"+tag.Url, "/"+tag.Url) {
+			files[p.File()] = "the page of the tag " + tag.Name
+		}
+		files[tag.Url+"feed.rss"] = "the feed of the tag " + tag.Name
+	}
+
+	// The sitemap never has more parts than there are pages.
+	parts := len(files) + len(entries) + len(list)
+	for i := 0; i < parts; i++ {
+		files[sitemap.PartFile(i)] = "the sitemap"
+	}
+
+	return files, nil
+}
+
+// outputFiles returns every file the build writes, except the aliases,
+// and what writes each of them: the entries and pages name their
+// source files. It fails on the first file two of them write.
+func outputFiles(entries []*blogs.BlogEntry, list []*pages.Page,
+	site *config.Config, static iofs.FS) (map[string]string, error) {
+
+	files, err := generatedFiles(entries, list, site, static)
+	if err != nil {
+		return nil, fmt.Errorf("listing generated files: %s", err)
+	}
+
+	all := append([]*blogs.BlogEntry{}, entries...)
+	for _, p := range list {
+		all = append(all, p.BlogEntry)
+	}
+
+	for _, be := range all {
+		if other, ok := files[be.File()]; ok {
+			return nil, BuildErrors{fmt.Errorf("%s and %s are both written to %s",
+				other, be.Path, be.File())}
+		}
+		files[be.File()] = be.Path
+	}
+
+	return files, nil
+}
+
+// collectAliases returns the aliases of the given entries and pages.
+// An alias can't be where anything in files, the output files from
+// outputFiles, or another alias is written. The aliases are added to
+// files.
+func collectAliases(entries []*blogs.BlogEntry, list []*pages.Page,
+	files map[string]string) ([]aliases.Alias, error) {
+
+	all := append([]*blogs.BlogEntry{}, entries...)
+	for _, p := range list {
+		all = append(all, p.BlogEntry)
+	}
+
+	result := []aliases.Alias{}
+	for _, be := range all {
+		for _, from := range be.Aliases {
+			a := aliases.New(from, be.Url)
+			if a.From == "" {
+				return nil, fmt.Errorf("%s: an alias can't replace the index page",
+					be.Path)
+			}
+			if !a.Valid() {
+				return nil, fmt.Errorf("%s: alias %q has whitespace in it",
+					be.Path, from)
+			}
+			if other, ok := files[a.File()]; ok {
+				return nil, fmt.Errorf("%s: alias %s would overwrite %s",
+					be.Path, from, other)
+			}
+			files[a.File()] = be.Path
+			result = append(result, a)
+		}
+	}
+
+	return result, nil
+}
+
+// siteWithPages returns a copy of the Site with the pages that ask for
+// it added to the menus.
+func siteWithPages(list []*pages.Page) *config.Config {
+	site := *Site
+	site.Menus = map[string][]config.Link{}
+	for name, links := range Site.Menus {
+		site.Menus[name] = append([]config.Link{}, links...)
+	}
+
+	for _, p := range list {
+		if p.Menu == "" {
+			continue
+		}
+
+		site.Menus[p.Menu] = append(site.Menus[p.Menu], config.Link{
+			Name:   p.Title,
+			URL:    "/" + p.Url,
+			Weight: p.Weight,
+		})
+	}
+	site.SortMenus()
+
+	return &site
+}
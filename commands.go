@@ -0,0 +1,319 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	flag "github.com/ogier/pflag"
+	"github.com/pyanfield/goblog/blogs"
+	"github.com/pyanfield/goblog/pages"
+	"github.com/pyanfield/goblog/rss"
+	"github.com/pyanfield/goblog/templates"
+	iofs "io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// command is one of the goblog sub-commands.
+type command struct {
+	// Name is what the user types to run the command.
+	Name string
+
+	// Args describes the arguments of the command for the usage.
+	Args string
+
+	// Short is a one line description of the command.
+	Short string
+
+	// Workspace is true if the command needs the workspace
+	// directories set up before it runs.
+	Workspace bool
+
+	// Run runs the command with the arguments that follow its name.
+	Run func(args []string) error
+}
+
+// commands are all of the goblog sub-commands in the order they are
+// listed in the usage.
+var commands = []*command{
+	{
+		Name:  "init",
+		Args:  "[dir]",
+		Short: "create a new workspace with a working default theme",
+		Run:   runInit,
+	},
+	{
+		Name:      "new",
+		Args:      "<title>",
+		Short:     "create a new blog entry",
+		Workspace: true,
+		Run:       runNew,
+	},
+	{
+		Name:      "build",
+		Short:     "generate the site into the output directory (default)",
+		Workspace: true,
+		Run:       runBuild,
+	},
+	{
+		Name:      "serve",
+		Short:     "preview the site and rebuild it when files change",
+		Workspace: true,
+		Run:       runServe,
+	},
+	{
+		Name:      "check",
+		Short:     "look for problems in the blog entries and templates",
+		Workspace: true,
+		Run:       runCheck,
+	},
+	{
+		Name:      "list",
+		Short:     "list the blog entries with their state, dates and tags",
+		Workspace: true,
+		Run:       runList,
+	},
+}
+
+// findCommand returns the command with the given name or nil if there
+// isn't one.
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+// usage prints the commands and flags.
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: goblog [flags] [command] [args]\n\n")
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+
+	w := tabwriter.NewWriter(os.Stderr, 0, 8, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s %s\t%s\n", cmd.Name, cmd.Args, cmd.Short)
+	}
+	w.Flush()
+
+	fmt.Fprintf(os.Stderr, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+// runBuild generates the site.
+func runBuild(args []string) error {
+	err := prepareOutputDir()
+	if err != nil {
+		return err
+	}
+
+	return buildSite()
+}
+
+// runServe previews the site.
+func runServe(args []string) error {
+	return serve()
+}
+
+// runNew creates a new blog entry with the given title. The file name
+// comes from the title and the metadata is filled in so the entry
+// only needs writing. New entries are drafts.
+// 根据标题创建一篇新的博客，文件名由标题生成，并且预先填好元数据
+func runNew(args []string) error {
+	title := strings.TrimSpace(strings.Join(args, " "))
+	if title == "" {
+		return fmt.Errorf("usage: goblog new <title>")
+	}
+
+	name, err := blogs.MakeBlogName(strings.ToLower(title))
+	if err != nil {
+		return err
+	}
+
+	// Tidy up the dashes MakeBlogName left for the punctuation.
+	name = strings.Trim(regexp.MustCompile("-+").ReplaceAllString(name, "-"), "-")
+	if name == "" {
+		return fmt.Errorf("can't make a file name from %q", title)
+	}
+
+	file := path.Join(BlogDir, name+".md")
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%s already exists", file)
+		}
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "---\ntitle: %s\ndate: %s\ndraft: true\ntags: []\n---\n\n",
+		strconv.Quote(title), time.Now().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("created", file)
+	return nil
+}
+
+// parseAll gets and parses every blog entry, including the ones that
+// wouldn't be published. The entries that parsed are returned along
+// with a BuildErrors for the ones that didn't.
+func parseAll() ([]*blogs.BlogEntry, error) {
+	entries, err := blogs.GetBlogFiles(BlogDir)
+	if err != nil {
+		return nil, fmt.Errorf("getting blog file list: %s", err)
+	}
+
+	parsed := make([]bool, len(entries))
+	err = forEach(len(entries), Jobs, func(i int) error {
+		_, err := entries[i].Parse()
+		if err != nil {
+			return fmt.Errorf("%s: %s", entries[i].Path, err)
+		}
+
+		entries[i].Url, err = entries[i].Permalink(Site.Permalink)
+		if err != nil {
+			return fmt.Errorf("%s: %s", entries[i].Path, err)
+		}
+		parsed[i] = true
+		return nil
+	})
+
+	ok := make([]*blogs.BlogEntry, 0, len(entries))
+	for i, be := range entries {
+		if parsed[i] {
+			ok = append(ok, be)
+		}
+	}
+
+	return ok, err
+}
+
+// runList prints every blog entry, newest first, with its state, dates
+// and tags.
+// 列出所有的博客以及它们的状态，日期和标签
+func runList(args []string) error {
+	entries, err := parseAll()
+	if _, ok := err.(BuildErrors); !ok && err != nil {
+		return err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[j].Created.Before(entries[i].Created)
+	})
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "STATE\tCREATED\tUPDATED\tTITLE\tTAGS\tPATH")
+	for _, be := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", be.State(now), be.CDate(),
+			be.Updated.Format("2006-01-02"), be.Title,
+			strings.Join(be.Tags, ","), be.Path)
+	}
+
+	ferr := w.Flush()
+	if ferr != nil {
+		return ferr
+	}
+
+	// Report the entries that didn't parse after the ones that did.
+	return err
+}
+
+// runCheck parses every blog entry and page and loads the templates
+// without writing anything. It reports every problem it finds and fails
+// if there were any.
+// 检查所有的博客和模版是否有问题，但是不生成任何文件
+func runCheck(args []string) error {
+	problems := BuildErrors{}
+
+	tmpltFiles, err := themeFiles(TemplateDir, "templates")
+	if err != nil {
+		return err
+	}
+
+	// The site builds without the url, but not its feeds or sitemap.
+	if !rss.ValidLink(URL) && !rss.ValidLink(rss.ChannelLink(tmpltFiles)) {
+		fmt.Printf("url: %q isn't a full url, so there are no feeds or sitemap\n",
+			URL)
+	}
+
+	_, err = templates.LoadTemplates(tmpltFiles, Site)
+	if err != nil {
+		problems = append(problems, fmt.Errorf("templates: %s", err))
+	}
+
+	entries, err := parseAll()
+	if errs, ok := err.(BuildErrors); ok {
+		problems = append(problems, errs...)
+	} else if err != nil {
+		return err
+	}
+
+	// The standalone pages need to parse too, and their layouts must
+	// exist.
+	pageList, err := pages.GetPages(PageDir)
+	if err != nil {
+		return err
+	}
+	for _, p := range pageList {
+		err := p.Parse()
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %s", p.Path, err))
+			continue
+		}
+
+		if _, err := iofs.Stat(tmpltFiles, p.Layout+".html"); err != nil {
+			problems = append(problems,
+				fmt.Errorf("%s: no layout template %s.html", p.Path, p.Layout))
+		}
+	}
+
+	// The aliases can't replace anything.
+	staticFiles, err := themeFiles(StaticDir, "static")
+	if err != nil {
+		return err
+	}
+	files, err := outputFiles(entries, pageList, Site, staticFiles)
+	if errs, ok := err.(BuildErrors); ok {
+		problems = append(problems, errs...)
+	} else if err != nil {
+		return err
+	} else {
+		_, err = collectAliases(entries, pageList, files)
+		if err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	// Look for the mistakes that still parse.
+	for _, be := range entries {
+		if be.Title == "" {
+			problems = append(problems, fmt.Errorf("%s: no title", be.Path))
+		}
+
+		if !be.PublishDate.IsZero() && !be.ExpiryDate.IsZero() &&
+			!be.ExpiryDate.After(be.PublishDate) {
+			problems = append(problems,
+				fmt.Errorf("%s: expires before it's published", be.Path))
+		}
+	}
+
+	if len(problems) > 0 {
+		return problems
+	}
+
+	fmt.Printf("%d blog entries, no problems found\n", len(entries))
+	return nil
+}
@@ -0,0 +1,315 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+// Package config reads the site configuration file of a goblog
+// workspace. The values are available to every template as .Site.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FileNames are the names of the configuration files that are looked
+// for, in order. The first one found is used.
+var FileNames = []string{
+	"goblog.toml",
+	"goblog.yaml",
+	"goblog.yml",
+	"goblog.json",
+}
+
+// EnvPrefix is prepended to the environment variables that override
+// the configuration file (e.g. GOBLOG_TITLE).
+const EnvPrefix = "GOBLOG_"
+
+// Config is the site configuration. Empty values mean the setting
+// wasn't given.
+// 站点的配置信息，可以在所有的模版中通过 .Site 访问
+type Config struct {
+	// Title is the name of the site.
+	Title string `toml:"title" yaml:"title" json:"title"`
+
+	// Description is a short description of the site.
+	Description string `toml:"description" yaml:"description" json:"description"`
+
+	// Author is the name of the person who writes the site.
+	Author string `toml:"author" yaml:"author" json:"author"`
+
+	// Email is the email address of the Author.
+	Email string `toml:"email" yaml:"email" json:"email"`
+
+	// URL is the base url of the site (e.g. http://example.com/).
+	URL string `toml:"url" yaml:"url" json:"url"`
+
+	// Language is the language code of the site (e.g. en-us).
+	Language string `toml:"language" yaml:"language" json:"language"`
+
+	// Copyright is the copyright notice of the site.
+	Copyright string `toml:"copyright" yaml:"copyright" json:"copyright"`
+
+	// Image is the url of the logo of the site (e.g. /logo.png). The
+	// feeds use it.
+	Image string `toml:"image" yaml:"image" json:"image"`
+
+	// Theme is the name of the theme in the themes directory to use.
+	// The default theme is used when it's empty.
+	Theme string `toml:"theme" yaml:"theme" json:"theme"`
+
+	// OutputDir, TemplateDir, BlogDir, PageDir and StaticDir are the
+	// same as the flags with the same names.
+	OutputDir   string `toml:"output_dir" yaml:"output_dir" json:"output_dir"`
+	TemplateDir string `toml:"template_dir" yaml:"template_dir" json:"template_dir"`
+	BlogDir     string `toml:"blog_dir" yaml:"blog_dir" json:"blog_dir"`
+	PageDir     string `toml:"page_dir" yaml:"page_dir" json:"page_dir"`
+	StaticDir   string `toml:"static_dir" yaml:"static_dir" json:"static_dir"`
+
+	// Permalink is the pattern of the urls of the blog entries (e.g.
+	// "/:year/:month/:slug/"). See blogs.BlogEntry.Permalink for the
+	// tokens. The entries are at /NAME.html when it's empty.
+	Permalink string `toml:"permalink" yaml:"permalink" json:"permalink"`
+
+	// Redirects are the formats of the redirect files to write for the
+	// aliases of the entries and pages ("netlify", "nginx" and
+	// "apache"), in addition to the redirect pages.
+	Redirects []string `toml:"redirects" yaml:"redirects" json:"redirects"`
+
+	// FeedEntries is the number of the most recent entries in each
+	// feed. It's 10 when it's 0.
+	FeedEntries int `toml:"feed_entries" yaml:"feed_entries" json:"feed_entries"`
+
+	// FeedContent is how much of each entry is in the feeds: "summary"
+	// (the default), "excerpt" or "full".
+	FeedContent string `toml:"feed_content" yaml:"feed_content" json:"feed_content"`
+
+	// IndexEntries is the maximum number of entries on each page of
+	// the index.
+	IndexEntries int `toml:"index_entries" yaml:"index_entries" json:"index_entries"`
+
+	// ArchiveEntries is the maximum number of entries on each page of
+	// the archives. The archives are on one page when it's 0.
+	ArchiveEntries int `toml:"archive_entries" yaml:"archive_entries" json:"archive_entries"`
+
+	// TagEntries is the maximum number of entries on each page of a
+	// tag. Each tag is on one page when it's 0.
+	TagEntries int `toml:"tag_entries" yaml:"tag_entries" json:"tag_entries"`
+
+	// RawTemplates turns off the escaping of the page templates for
+	// themes that expect the values to be output as they are.
+	RawTemplates bool `toml:"raw_templates" yaml:"raw_templates" json:"raw_templates"`
+
+	// Menus are named lists of links (e.g. "main") for the themes to
+	// use for navigation.
+	Menus map[string][]Link `toml:"menus" yaml:"menus" json:"menus"`
+
+	// Social are links to the author's profiles on other sites.
+	Social []Link `toml:"social" yaml:"social" json:"social"`
+
+	// Podcast describes the podcast made of the entries with an
+	// enclosure.
+	Podcast Podcast `toml:"podcast" yaml:"podcast" json:"podcast"`
+
+	// Params holds any other values a theme needs.
+	Params map[string]interface{} `toml:"params" yaml:"params" json:"params"`
+}
+
+// Podcast is what the podcast feed says about the show. The values that
+// are empty come from the site.
+type Podcast struct {
+	// Title is the name of the show.
+	Title string `toml:"title" yaml:"title" json:"title"`
+
+	// Description is what the show is about.
+	Description string `toml:"description" yaml:"description" json:"description"`
+
+	// Author is who makes the show.
+	Author string `toml:"author" yaml:"author" json:"author"`
+
+	// Email is the email address of the owner of the show.
+	Email string `toml:"email" yaml:"email" json:"email"`
+
+	// Image is the url of the artwork of the show.
+	Image string `toml:"image" yaml:"image" json:"image"`
+
+	// Categories are the iTunes categories of the show (e.g.
+	// Technology).
+	Categories []string `toml:"categories" yaml:"categories" json:"categories"`
+
+	// Explicit marks the show as having explicit content.
+	Explicit bool `toml:"explicit" yaml:"explicit" json:"explicit"`
+}
+
+// Link is a named link used for menus and social links.
+type Link struct {
+	// Name is the text of the link.
+	Name string `toml:"name" yaml:"name" json:"name"`
+
+	// URL is where the link goes.
+	URL string `toml:"url" yaml:"url" json:"url"`
+
+	// Weight orders the links, lowest first.
+	Weight int `toml:"weight" yaml:"weight" json:"weight"`
+}
+
+// Load reads the first of the FileNames found in dir. It returns the
+// configuration and the file it came from. If there is no
+// configuration file, an empty Config and "" are returned.
+// 在 dir 文件夹中查找并读取配置文件
+func Load(dir string) (*Config, string, error) {
+	for _, name := range FileNames {
+		file := path.Join(dir, name)
+
+		contents, err := ioutil.ReadFile(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, "", err
+		}
+
+		c, err := Parse(name, contents)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %s", file, err)
+		}
+
+		return c, file, nil
+	}
+
+	return &Config{}, "", nil
+}
+
+// Parse decodes the given contents. The format comes from the
+// extension of name. The links of each menu are sorted by Weight.
+func Parse(name string, contents []byte) (*Config, error) {
+	c := &Config{}
+
+	var err error
+	switch path.Ext(name) {
+	case ".toml":
+		_, err = toml.Decode(string(contents), c)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(contents, c)
+	case ".json":
+		err = json.Unmarshal(contents, c)
+	default:
+		err = fmt.Errorf("unknown configuration format: %s", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Put the menus in order so the themes don't have to.
+	c.SortMenus()
+
+	return c, nil
+}
+
+// SortMenus sorts the links of each menu by Weight. Links with the same
+// Weight keep their order.
+func (c *Config) SortMenus() {
+	for _, links := range c.Menus {
+		sort.SliceStable(links, func(i, j int) bool {
+			return links[i].Weight < links[j].Weight
+		})
+	}
+}
+
+// ApplyEnv overrides the configuration with the environment variables
+// that are set. The variables are the EnvPrefix followed by the upper
+// case name of the setting (e.g. GOBLOG_URL or GOBLOG_OUTPUT_DIR).
+func (c *Config) ApplyEnv(getenv func(string) string) error {
+	strs := map[string]*string{
+		"TITLE":        &c.Title,
+		"DESCRIPTION":  &c.Description,
+		"AUTHOR":       &c.Author,
+		"EMAIL":        &c.Email,
+		"URL":          &c.URL,
+		"LANGUAGE":     &c.Language,
+		"COPYRIGHT":    &c.Copyright,
+		"IMAGE":        &c.Image,
+		"THEME":        &c.Theme,
+		"OUTPUT_DIR":   &c.OutputDir,
+		"TEMPLATE_DIR": &c.TemplateDir,
+		"BLOG_DIR":     &c.BlogDir,
+		"PAGE_DIR":     &c.PageDir,
+		"STATIC_DIR":   &c.StaticDir,
+		"PERMALINK":    &c.Permalink,
+		"FEED_CONTENT": &c.FeedContent,
+	}
+	for name, value := range strs {
+		if v := getenv(EnvPrefix + name); v != "" {
+			*value = v
+		}
+	}
+
+	ints := map[string]*int{
+		"INDEX_ENTRIES":   &c.IndexEntries,
+		"ARCHIVE_ENTRIES": &c.ArchiveEntries,
+		"TAG_ENTRIES":     &c.TagEntries,
+		"FEED_ENTRIES":    &c.FeedEntries,
+	}
+	for name, value := range ints {
+		if v := getenv(EnvPrefix + name); v != "" {
+			i, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("%s%s: %s", EnvPrefix, name, err)
+			}
+			*value = i
+		}
+	}
+
+	if v := getenv(EnvPrefix + "RAW_TEMPLATES"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%sRAW_TEMPLATES: %s", EnvPrefix, err)
+		}
+		c.RawTemplates = b
+	}
+
+	return nil
+}
+
+// MarkerFile marks the root of a workspace that doesn't have a
+// configuration file.
+const MarkerFile = ".goblog"
+
+// FindWorkspace looks for a configuration file (one of the FileNames)
+// or the MarkerFile in start and then in each of its parents. It
+// returns the first directory that has one.
+// 从 start 开始逐级向上查找包含配置文件或者 .goblog 文件的文件夹，作为博客的工作区
+func FindWorkspace(start string) (string, error) {
+	start, err := filepath.Abs(start)
+	if err != nil {
+		return "", err
+	}
+	dir := start
+
+	names := append([]string{MarkerFile}, FileNames...)
+	for {
+		for _, name := range names {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return dir, nil
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return "", fmt.Errorf("no goblog workspace found in %s or any of its "+
+		"parents (looked for %s).\nRun 'goblog init' to create one or "+
+		"use --working-dir to point at one", start, strings.Join(names, ", "))
+}
@@ -0,0 +1,136 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestParse makes sure the three formats decode to the same Config.
+func TestParse(t *testing.T) {
+	expected := &Config{
+		Title:        "My Blog",
+		URL:          "http://example.com/",
+		IndexEntries: 5,
+		Menus: map[string][]Link{
+			"main": []Link{{Name: "Home", URL: "/", Weight: 1}},
+		},
+		Social: []Link{{Name: "GitHub", URL: "https://github.com/"}},
+	}
+
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{
+			name: "goblog.toml",
+			contents: `title = "My Blog"
+url = "http://example.com/"
+index_entries = 5
+
+[[menus.main]]
+name = "Home"
+url = "/"
+weight = 1
+
+[[social]]
+name = "GitHub"
+url = "https://github.com/"
+`,
+		},
+		{
+			name: "goblog.yaml",
+			contents: `title: My Blog
+url: http://example.com/
+index_entries: 5
+menus:
+  main:
+    - name: Home
+      url: /
+      weight: 1
+social:
+  - name: GitHub
+    url: https://github.com/
+`,
+		},
+		{
+			name: "goblog.json",
+			contents: `{"title": "My Blog", "url": "http://example.com/",
+"index_entries": 5,
+"menus": {"main": [{"name": "Home", "url": "/", "weight": 1}]},
+"social": [{"name": "GitHub", "url": "https://github.com/"}]}`,
+		},
+	}
+
+	for i, test := range tests {
+		c, err := Parse(test.name, []byte(test.contents))
+		if err != nil {
+			t.Errorf("(%d) unexpected error for %s: %s", i, test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(c, expected) {
+			t.Errorf("(%d) expecting %+v from %s but got %+v", i, expected,
+				test.name, c)
+		}
+	}
+}
+
+// TestApplyEnv makes sure the environment overrides the file.
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"GOBLOG_TITLE":         "From Env",
+		"GOBLOG_INDEX_ENTRIES": "7",
+	}
+
+	c := &Config{Title: "From File", Author: "From File"}
+	err := c.ApplyEnv(func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if c.Title != "From Env" || c.Author != "From File" || c.IndexEntries != 7 {
+		t.Errorf("unexpected config after ApplyEnv: %+v", c)
+	}
+}
+
+// TestFindWorkspace makes sure the workspace is found from a
+// sub-directory and that a missing one is an error.
+func TestFindWorkspace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "workspace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sub := filepath.Join(dir, "blogs", "go")
+	if err := os.MkdirAll(sub, 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing marks the workspace yet. The temp directory's parents
+	// shouldn't have one either.
+	if _, err := FindWorkspace(sub); err == nil {
+		t.Errorf("expecting an error without a configuration file")
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, "goblog.yaml"), nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := FindWorkspace(sub)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if found != dir {
+		t.Errorf("expecting %s but got %s", dir, found)
+	}
+}
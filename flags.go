@@ -6,10 +6,13 @@ package main
 
 import (
 	flag "github.com/ogier/pflag"
+	"runtime"
 )
 
 // WorkingDir is the directory where that should be prepended to all
-// the other configurable directories.
+// the other configurable directories. If it isn't given, the workspace
+// is found by looking for a configuration file in the current directory
+// and its parents.
 // 你的 blog 所有静态内容所在的文件夹
 var WorkingDir string
 
@@ -30,11 +33,16 @@ var TemplateDir string
 // markdown 文件存放的位置
 var BlogDir string
 
+// PageDir is the directory where the standalone pages can be found.
+// 独立页面的 markdown 文件存放的位置
+var PageDir string
+
 // StaticDir is the directory where static assests can be found.
 var StaticDir string
 
-// URL is the url for this site. The RSS feed will use it to generate links.
-// 本站点的 URL 地址，这个主要会用于生成 RSS 的时候使用
+// URL is the full url of this site. The feeds and the sitemap use it
+// to generate links.
+// 本站点的 URL 地址，这个主要会用于生成 RSS 和 sitemap 的时候使用
 var URL string
 
 // MaxIndexEntries is the maximum number of entries to display on the
@@ -42,10 +50,47 @@ var URL string
 // 在每页最多索引几篇内容
 var MaxIndexEntries int
 
+// BuildDrafts determines whether or not entries marked as drafts are
+// rendered.
+// 是否生成草稿
+var BuildDrafts bool
+
+// BuildFuture determines whether or not entries with a PublishDate in
+// the future are rendered.
+// 是否生成还没有到发布时间的博客
+var BuildFuture bool
+
+// BuildExpired determines whether or not entries with an ExpiryDate in
+// the past are rendered.
+var BuildExpired bool
+
+// Jobs is the number of goroutines used to parse and render the blog
+// entries.
+// 并发解析和生成页面的 goroutine 数量
+var Jobs int
+
+// Force determines whether or not every page and static file is
+// generated even if its inputs haven't changed since the last build.
+// 是否忽略上一次生成的记录，强制重新生成所有的文件
+var Force bool
+
+// RawTemplates determines whether or not the page templates are
+// executed without escaping, like goblog did before. Older themes that
+// build HTML out of the metadata need it.
+// 是否关闭模版的自动转义，兼容旧的模版
+var RawTemplates bool
+
+// Addr is the address the development server listens on.
+// 本地预览服务器监听的地址
+var Addr string
+
 func init() {
-	flag.StringVarP(&WorkingDir, "working-dir", "w", "./",
+	flag.StringVarP(&WorkingDir, "working-dir", "w", "",
 		"The directory where all the other directories reside. This "+
-			"will be prepended to the rest of the configurable directories.")
+			"will be prepended to the rest of the configurable directories. "+
+			"Defaults to the first directory, starting with the current one "+
+			"and going up, that has a goblog.toml (or .yaml, .yml, .json) "+
+			"or .goblog file.")
 
 	flag.StringVarP(&OutputDir, "output-dir", "o", "public",
 		"The directory where the results should be placed.")
@@ -59,12 +104,41 @@ func init() {
 	flag.StringVarP(&BlogDir, "blog-dir", "b", "blogs",
 		"The directory where the blogs are located.")
 
+	flag.StringVarP(&PageDir, "page-dir", "p", "pages",
+		"The directory where the standalone pages are located.")
+
 	flag.StringVarP(&StaticDir, "static-dir", "s", "static",
 		"The directory where the static assets are located.")
 
 	flag.StringVarP(&URL, "url", "u", "",
-		"The url to be prepended to link in the RSS feed. Defaults to the value in the channel <link>.")
+		"The full url of the site (e.g. http://example.com/) used for the "+
+			"links in the feeds and the sitemap. Without this flag, it "+
+			"comes from GOBLOG_URL or the url in goblog.toml.")
 
 	flag.IntVarP(&MaxIndexEntries, "index-entries", "i", 3,
 		"The maximum number of entries to display on the index page.")
+
+	flag.BoolVarP(&BuildDrafts, "drafts", "D", false,
+		"Render the entries that are marked as drafts.")
+
+	flag.BoolVarP(&BuildFuture, "future", "F", false,
+		"Render the entries whose publish date is in the future.")
+
+	flag.BoolVarP(&BuildExpired, "expired", "E", false,
+		"Render the entries whose expiry date has passed.")
+
+	flag.IntVarP(&Jobs, "jobs", "j", runtime.NumCPU(),
+		"The number of blog entries to parse and render at the same time.")
+
+	flag.BoolVarP(&Force, "force", "f", false,
+		"Regenerate every page and copy every static file even if "+
+			"nothing changed since the last build.")
+
+	flag.BoolVarP(&RawTemplates, "raw-templates", "r", false,
+		"Execute the page templates with text/template so nothing is "+
+			"escaped. Only needed for themes that output HTML from the "+
+			"metadata.")
+
+	flag.StringVarP(&Addr, "addr", "a", "localhost:8080",
+		"The address to listen on when serving the site with 'goblog serve'.")
 }
@@ -0,0 +1,301 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// commitMarker starts the line that git log prints for each commit.
+// It is followed by the author time as a UNIX timestamp.
+const commitMarker = "\x1e"
+
+// GitHistory is the first and last commit time of every file in a git
+// repository. It is built from a single walk of the history, so
+// looking up the times for a file never runs git again.
+// 通过一次 git log 得到仓库中所有文件第一次和最后一次提交的时间
+type GitHistory struct {
+	// Root is the top level directory of the repository.
+	Root string
+
+	// files maps the slash separated path of a file relative to Root
+	// to its commit times.
+	files map[string]*commitTimes
+}
+
+// commitTimes are the times of the first and last commits that touched
+// a file.
+type commitTimes struct {
+	first time.Time
+	last  time.Time
+}
+
+// histories caches the GitHistory for each repository root so the
+// history is only walked once per build. A nil value means the history
+// couldn't be loaded and the file system times should be used.
+var (
+	histories   = map[string]*GitHistory{}
+	historiesMu sync.Mutex
+)
+
+// ResetGitHistories forgets the cached histories. Each build calls it
+// first, so a long running goblog serve sees the commits made since the
+// last one.
+func ResetGitHistories() {
+	historiesMu.Lock()
+	defer historiesMu.Unlock()
+
+	histories = map[string]*GitHistory{}
+}
+
+// LoadGitHistory walks the history of the git repository that
+// contains dir. Renames are followed, so a file that was moved keeps
+// the time of its original commit. The working directory of the
+// process is never changed.
+func LoadGitHistory(dir string) (*GitHistory, error) {
+	// Find the top level of the repository. git log prints paths
+	// relative to it.
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("finding git root of %s: %s", dir, err)
+	}
+	root := strings.TrimSpace(string(out))
+
+	// Stream the whole history, newest commit first.
+	cmd = exec.Command("git", "-c", "core.quotePath=false", "log",
+		"--no-merges", "--name-status", "-M",
+		"--format="+commitMarker+"%at", "HEAD")
+	cmd.Dir = root
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	files, perr := parseGitLog(stdout)
+
+	// Drain whatever is left so git can exit.
+	io.Copy(ioutil.Discard, stdout)
+	err = cmd.Wait()
+	if err != nil {
+		return nil, fmt.Errorf("git log: %s: %s", err,
+			strings.TrimSpace(stderr.String()))
+	}
+	if perr != nil {
+		return nil, perr
+	}
+
+	return &GitHistory{
+		Root:  root,
+		files: files,
+	}, nil
+}
+
+// Times returns the first and last commit times of the file at p. The
+// last value is false if git doesn't know about the file.
+func (gh *GitHistory) Times(p string) (time.Time, time.Time, bool) {
+	rel, err := relativePath(gh.Root, p)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	ct, ok := gh.files[rel]
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+
+	return ct.first, ct.last, true
+}
+
+// parseGitLog reads the output of git log --name-status (newest commit
+// first) and returns the commit times of every file. When a file was
+// renamed, the history of the old name is credited to the new name.
+// 解析 git log --name-status 的输出。如果文件被重命名过，那么旧文件名的历史也算到新文件名上。
+func parseGitLog(r io.Reader) (map[string]*commitTimes, error) {
+	files := map[string]*commitTimes{}
+
+	// renamed maps an old name to the name the file has now.
+	renamed := map[string]string{}
+
+	// deleted holds the files that were deleted and then added again.
+	// Commits from before the deletion belong to a different file.
+	deleted := map[string]bool{}
+
+	current := func(p string) string {
+		if n, ok := renamed[p]; ok {
+			return n
+		}
+		return p
+	}
+
+	touch := func(p string, t time.Time) {
+		if deleted[p] {
+			return
+		}
+
+		ct, ok := files[p]
+		if !ok {
+			files[p] = &commitTimes{first: t, last: t}
+			return
+		}
+
+		if t.Before(ct.first) {
+			ct.first = t
+		}
+		if t.After(ct.last) {
+			ct.last = t
+		}
+	}
+
+	var when time.Time
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		// A new commit.
+		if strings.HasPrefix(line, commitMarker) {
+			secs, err := strconv.ParseInt(
+				strings.TrimPrefix(line, commitMarker), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("bad commit time in %q", line)
+			}
+			when = time.Unix(secs, 0)
+			continue
+		}
+
+		// A file in the commit: "M\tpath" or "R100\told\tnew".
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		for i := 1; i < len(fields); i++ {
+			fields[i] = unquoteGitPath(fields[i])
+		}
+
+		switch fields[0][0] {
+		case 'R':
+			if len(fields) < 3 {
+				continue
+			}
+			name := current(fields[2])
+			touch(name, when)
+			renamed[fields[1]] = name
+		case 'D':
+			name := current(fields[1])
+			if _, ok := files[name]; ok {
+				deleted[name] = true
+			}
+		default:
+			touch(current(fields[1]), when)
+		}
+	}
+
+	return files, scanner.Err()
+}
+
+// unquoteGitPath removes the C style quoting git uses for unusual file
+// names.
+func unquoteGitPath(p string) string {
+	if !strings.HasPrefix(p, `"`) {
+		return p
+	}
+
+	u, err := strconv.Unquote(p)
+	if err != nil {
+		return p
+	}
+
+	return u
+}
+
+// relativePath returns p relative to root as a slash separated path.
+// Symbolic links are resolved on both so they agree with git.
+func relativePath(root, p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+
+	if r, err := filepath.EvalSymlinks(root); err == nil {
+		root = r
+	}
+	if a, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = a
+	}
+
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+// gitHistoryFor returns the cached GitHistory for the repository that
+// contains p, loading it the first time it's needed. It returns nil if
+// p isn't in a git repository or the history couldn't be read.
+func gitHistoryFor(p string) *GitHistory {
+	root := findGitRoot(p)
+	if root == "" {
+		return nil
+	}
+
+	historiesMu.Lock()
+	defer historiesMu.Unlock()
+
+	gh, ok := histories[root]
+	if !ok {
+		// Remember failures too so we only try once.
+		gh, _ = LoadGitHistory(root)
+		histories[root] = gh
+	}
+
+	return gh
+}
+
+// findGitRoot looks for a .git directory (or file, for worktrees) in
+// the directory of p and all of its parents. It returns "" if there
+// isn't one.
+func findGitRoot(p string) string {
+	dir, err := filepath.Abs(filepath.Dir(p))
+	if err != nil {
+		return ""
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
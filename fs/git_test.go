@@ -0,0 +1,70 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package fs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseGitLog tests parseGitLog with renames and deletions.
+func TestParseGitLog(t *testing.T) {
+	// Newest commit first, just like git log.
+	log := strings.Join([]string{
+		"\x1e500",
+		"",
+		"M\tblogs/go/moved.md",
+		"A\tblogs/again.md",
+		"\x1e400",
+		"",
+		"R100\tblogs/old.md\tblogs/go/moved.md",
+		"D\tblogs/again.md",
+		"\x1e300",
+		"",
+		"M\tblogs/old.md",
+		"M\tblogs/again.md",
+		"\x1e200",
+		"",
+		"A\tblogs/old.md",
+		"A\tblogs/again.md",
+		"A\t\"blogs/caf\\303\\251.md\"",
+	}, "\n")
+
+	files, err := parseGitLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		path        string
+		first, last int64
+	}{
+		// The rename carries the old history along.
+		{"blogs/go/moved.md", 200, 500},
+		// The history before the delete belongs to another file.
+		{"blogs/again.md", 500, 500},
+		// Quoted names are unquoted.
+		{"blogs/café.md", 200, 200},
+	}
+
+	for i, test := range tests {
+		ct, ok := files[test.path]
+		if !ok {
+			t.Errorf("(%d) expecting %s to be found", i, test.path)
+			continue
+		}
+
+		if !ct.first.Equal(time.Unix(test.first, 0)) {
+			t.Errorf("(%d) expecting first %d for %s but got %d", i,
+				test.first, test.path, ct.first.Unix())
+		}
+
+		if !ct.last.Equal(time.Unix(test.last, 0)) {
+			t.Errorf("(%d) expecting last %d for %s but got %d", i,
+				test.last, test.path, ct.last.Unix())
+		}
+	}
+}
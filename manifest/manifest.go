@@ -0,0 +1,210 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+// Package manifest keeps track of the inputs that were used to
+// generate each file of the site so that a rebuild only rewrites the
+// files whose inputs changed.
+package manifest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+)
+
+// FileName is the name of the manifest file within the output
+// directory.
+const FileName = ".goblog-manifest.json"
+
+// version is the version of the saved manifest. It changes when the
+// hashes are made differently, so the old ones don't mean anything.
+const version = 1
+
+// Manifest maps each generated file (relative to the output
+// directory) to the hash of the inputs it was generated from. It is
+// safe to use from multiple goroutines.
+// 记录每个生成的文件以及生成它时输入内容的哈希值，用于增量生成
+type Manifest struct {
+	// path is where the manifest is saved.
+	path string
+
+	// old are the hashes from the previous build.
+	old map[string]string
+
+	// current are the hashes recorded during this build.
+	current map[string]string
+
+	mu sync.Mutex
+}
+
+// manifestFile is the structure of the saved manifest.
+type manifestFile struct {
+	Version int
+	Files   map[string]string
+}
+
+// New returns an empty Manifest that will be saved to the given
+// file. Every file is considered changed.
+func New(file string) *Manifest {
+	return &Manifest{
+		path:    file,
+		old:     map[string]string{},
+		current: map[string]string{},
+	}
+}
+
+// Load reads the Manifest saved at the given file. A missing file
+// isn't an error; an empty Manifest is returned instead. When the file
+// was saved by another version, every file is considered changed, but
+// the files that aren't generated anymore are still Stale.
+func Load(file string) (*Manifest, error) {
+	m := New(file)
+
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, err
+	}
+
+	mf := manifestFile{}
+	err = json.Unmarshal(contents, &mf)
+	if err != nil {
+		return nil, err
+	}
+
+	if mf.Files != nil {
+		m.old = mf.Files
+	}
+	if mf.Version != version {
+		for file := range m.old {
+			m.old[file] = ""
+		}
+	}
+
+	return m, nil
+}
+
+// Changed returns true if the given file was generated from different
+// inputs (or not at all) in the previous build.
+func (m *Manifest) Changed(file, hash string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.old[file]
+	return !ok || old != hash
+}
+
+// Set records that the given file was generated (or is still valid)
+// with the given inputs during this build.
+func (m *Manifest) Set(file, hash string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.current[file] = hash
+}
+
+// Stale returns the files that were generated by the previous build
+// but not by this one, sorted by name.
+func (m *Manifest) Stale() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stale := []string{}
+	for file := range m.old {
+		if _, ok := m.current[file]; !ok {
+			stale = append(stale, file)
+		}
+	}
+	sort.Strings(stale)
+
+	return stale
+}
+
+// Save writes the files recorded during this build to disk. They
+// become the previous build for the next run.
+func (m *Manifest) Save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	contents, err := json.MarshalIndent(manifestFile{
+		Version: version,
+		Files:   m.current,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(m.path, contents, 0644)
+}
+
+// HashStrings returns a hash of all of the given strings.
+func HashStrings(parts ...string) string {
+	h := sha1.New()
+	for _, part := range parts {
+		io.WriteString(h, part)
+		// Separate the parts so ("ab", "c") and ("a", "bc") differ.
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// HashFile returns a hash of the contents of the given file.
+func HashFile(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashFS returns a hash of the names and contents of every file in the
+// given file system. A missing directory hashes the same as an empty
+// one.
+func HashFS(fsys fs.FS) (string, error) {
+	parts := []string{}
+
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == "." {
+				return nil
+			}
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		contents, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+
+		parts = append(parts, p, HashStrings(string(contents)))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return HashStrings(parts...), nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestManifest runs two builds through a Manifest and checks what is
+// changed and what is stale.
+func TestManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, FileName)
+
+	// The first build has nothing to compare to.
+	m, err := Load(file)
+	if err != nil {
+		t.Fatalf("loading a missing manifest: %s", err)
+	}
+	if !m.Changed("index.html", "a") {
+		t.Errorf("expecting index.html to be changed in the first build")
+	}
+	m.Set("index.html", "a")
+	m.Set("old.html", "b")
+	if err := m.Save(); err != nil {
+		t.Fatalf("saving: %s", err)
+	}
+
+	// The second build only generates index.html.
+	m, err = Load(file)
+	if err != nil {
+		t.Fatalf("loading: %s", err)
+	}
+	if m.Changed("index.html", "a") {
+		t.Errorf("expecting index.html to be unchanged")
+	}
+	if !m.Changed("index.html", "c") {
+		t.Errorf("expecting index.html to be changed with a new hash")
+	}
+	m.Set("index.html", "a")
+
+	if stale := m.Stale(); !reflect.DeepEqual(stale, []string{"old.html"}) {
+		t.Errorf("expecting [old.html] to be stale but got %v", stale)
+	}
+}
+
+// TestOtherVersion makes sure a manifest saved by another version
+// rebuilds everything but still finds the stale files.
+func TestOtherVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, FileName)
+
+	contents := `{"Version": 0, "Files": {"index.html": "a", "old.html": "b"}}`
+	if err := ioutil.WriteFile(file, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Load(file)
+	if err != nil {
+		t.Fatalf("loading: %s", err)
+	}
+	if !m.Changed("index.html", "a") {
+		t.Errorf("expecting index.html to be changed")
+	}
+	m.Set("index.html", "a")
+
+	if stale := m.Stale(); !reflect.DeepEqual(stale, []string{"old.html"}) {
+		t.Errorf("expecting [old.html] to be stale but got %v", stale)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+// Package pages contains structures and functions for the standalone
+// pages of a site (e.g. /projects/ or /now/). Pages are markdown files
+// like blog entries but they aren't part of the index, the tags, the
+// archives or the feed.
+package pages
+
+import (
+	"fmt"
+	"github.com/pyanfield/goblog/blogs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultLayout is the template pages are rendered with unless they
+// ask for another one.
+const DefaultLayout = "page"
+
+// Page is a standalone page. All of the BlogEntry values are available
+// to the templates, the metadata in .Params included. The Section of a
+// page is the first directory of its Url (e.g. "projects" for both
+// /projects/ and /projects/goblog/). It's used by the templates to tell
+// which part of the site a page is in.
+type Page struct {
+	*blogs.BlogEntry
+
+	// Layout is the name of the template (without .html) the content
+	// of the page is rendered with. It comes from the layout metadata
+	// and is DefaultLayout if there isn't any.
+	Layout string
+
+	// Menu is the name of the menu the page is added to (e.g. "main")
+	// or "" if it isn't in a menu. It comes from the menu metadata.
+	Menu string
+
+	// Weight orders the page within its menu, lowest first. It comes
+	// from the weight metadata.
+	Weight int
+}
+
+// Parse parses the page's markdown file like BlogEntry.Parse and then
+// reads the layout, menu and weight from the metadata.
+// 解析页面，并且从元数据中读取 layout, menu 和 weight
+func (p *Page) Parse() error {
+	_, err := p.BlogEntry.Parse()
+	if err != nil {
+		return err
+	}
+
+	p.Layout = DefaultLayout
+	if v, ok := p.Params["layout"]; ok && fmt.Sprint(v) != "" {
+		p.Layout = fmt.Sprint(v)
+	}
+
+	if v, ok := p.Params["menu"]; ok {
+		p.Menu = fmt.Sprint(v)
+	}
+
+	if v, ok := p.Params["weight"]; ok {
+		p.Weight, err = strconv.Atoi(strings.TrimSpace(fmt.Sprint(v)))
+		if err != nil {
+			return fmt.Errorf("weight: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// GetPages looks in the given directory and its sub-directories for
+// pages. Each .md file becomes a page at its own path: projects.md is
+// /projects/ and projects/goblog.md is /projects/goblog/. An index.md
+// is the page of its directory (projects/index.md is /projects/). The
+// pages are not parsed. A missing directory has no pages.
+// 返回 dir 文件夹下所有的页面，每个 md 文件都会生成在与其路径相同的地址
+func GetPages(dir string) ([]*Page, error) {
+	pages := []*Page{}
+
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == dir {
+				return nil
+			}
+			return err
+		}
+
+		if fi.IsDir() || path.Ext(p) != ".md" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimSuffix(filepath.ToSlash(rel), ".md")
+		if path.Base(name) == "index" {
+			name = path.Dir(name)
+		}
+		if name == "." {
+			return fmt.Errorf("%s: a page can't replace the index page", p)
+		}
+
+		pages = append(pages, &Page{
+			BlogEntry: &blogs.BlogEntry{
+				Name:    name,
+				Url:     name + "/",
+				Path:    p,
+				Section: strings.Split(name, "/")[0],
+			},
+		})
+		return nil
+	})
+
+	return pages, err
+}
+
+// Filter returns the pages that should be published right now, like
+// blogs.Filter does for blog entries. The pages must already be parsed.
+func Filter(pages []*Page, drafts, future, expired bool) []*Page {
+	now := time.Now()
+	visible := make([]*Page, 0, len(pages))
+
+	for _, p := range pages {
+		if p.Visible(now, drafts, future, expired) {
+			visible = append(visible, p)
+		}
+	}
+
+	return visible
+}
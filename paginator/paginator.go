@@ -0,0 +1,130 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+// Package paginator splits long listings (like the index) into pages
+// and gives the templates what they need to link between them.
+package paginator
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Paginator is one page of a listing. It's passed to the templates as
+// .Paginator. The urls start at the root of the site (e.g. /page/2/)
+// and are "" when there isn't such a page.
+// 分页信息，包括当前页，总页数以及上一页和下一页的地址
+type Paginator struct {
+	// Page is the number of this page, starting at 1.
+	Page int
+
+	// TotalPages is the number of pages in the listing.
+	TotalPages int
+
+	// TotalItems is the number of items in the whole listing.
+	TotalItems int
+
+	// PerPage is the most items there are on a page.
+	PerPage int
+
+	// Start and End are the range of the items of the listing that are
+	// on this page, items[Start:End].
+	Start, End int
+
+	// Url is the url of this page.
+	Url string
+
+	// First, Last, Prev and Next are the urls of the first, last,
+	// previous and next pages.
+	First, Last, Prev, Next string
+
+	// first and prefix make the urls, see URL.
+	first, prefix string
+}
+
+// Paginate splits a listing of total items into pages of perPage items.
+// The first page is at the url first (e.g. / or /archives.html) and
+// page n is at prefix + "page/n/" (e.g. /page/2/). A perPage less than 1
+// puts everything on one page. There is always at least one page, even
+// if it is empty.
+// 将 total 个条目按照每页 perPage 个分页
+func Paginate(total, perPage int, first, prefix string) []*Paginator {
+	if perPage < 1 {
+		perPage = total
+	}
+
+	pages := 1
+	if perPage > 0 && total > perPage {
+		pages = (total + perPage - 1) / perPage
+	}
+
+	result := make([]*Paginator, 0, pages)
+	for n := 1; n <= pages; n++ {
+		p := &Paginator{
+			Page:       n,
+			TotalPages: pages,
+			TotalItems: total,
+			PerPage:    perPage,
+			first:      first,
+			prefix:     prefix,
+		}
+
+		p.Start = (n - 1) * perPage
+		p.End = p.Start + perPage
+		if p.End > total {
+			p.End = total
+		}
+
+		p.Url = p.URL(n)
+		p.First = p.URL(1)
+		p.Last = p.URL(pages)
+		if n > 1 {
+			p.Prev = p.URL(n - 1)
+		}
+		if n < pages {
+			p.Next = p.URL(n + 1)
+		}
+
+		result = append(result, p)
+	}
+
+	return result
+}
+
+// URL returns the url of page n of the listing, e.g. {{.Paginator.URL
+// 3}}. It's "" if there is no such page.
+func (p *Paginator) URL(n int) string {
+	if n < 1 || n > p.TotalPages {
+		return ""
+	}
+
+	if n == 1 {
+		return p.first
+	}
+
+	return p.prefix + "page/" + strconv.Itoa(n) + "/"
+}
+
+// Numbers returns the numbers of all of the pages for templates that
+// link to each one, e.g. {{range .Paginator.Numbers}}.
+func (p *Paginator) Numbers() []int {
+	numbers := make([]int, p.TotalPages)
+	for i := range numbers {
+		numbers[i] = i + 1
+	}
+
+	return numbers
+}
+
+// File returns the file this page is written to, relative to the
+// output directory. Urls that end with a slash are directories with an
+// index.html.
+func (p *Paginator) File() string {
+	file := strings.TrimPrefix(p.Url, "/")
+	if file == "" || strings.HasSuffix(file, "/") {
+		file += "index.html"
+	}
+
+	return file
+}
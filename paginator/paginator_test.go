@@ -0,0 +1,89 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package paginator
+
+import (
+	"testing"
+)
+
+// TestPaginate tests the pages made by Paginate.
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		total, perPage int
+		first, prefix  string
+		expected       []Paginator
+	}{
+		{0, 3, "/", "/", []Paginator{
+			{Page: 1, TotalPages: 1, Start: 0, End: 0, Url: "/",
+				First: "/", Last: "/"},
+		}},
+		{3, 3, "/", "/", []Paginator{
+			{Page: 1, TotalPages: 1, Start: 0, End: 3, Url: "/",
+				First: "/", Last: "/"},
+		}},
+		{7, 3, "/", "/", []Paginator{
+			{Page: 1, TotalPages: 3, Start: 0, End: 3, Url: "/",
+				First: "/", Last: "/page/3/", Next: "/page/2/"},
+			{Page: 2, TotalPages: 3, Start: 3, End: 6, Url: "/page/2/",
+				First: "/", Last: "/page/3/", Prev: "/", Next: "/page/3/"},
+			{Page: 3, TotalPages: 3, Start: 6, End: 7, Url: "/page/3/",
+				First: "/", Last: "/page/3/", Prev: "/page/2/"},
+		}},
+		{5, 0, "/archives.html", "/archives/", []Paginator{
+			{Page: 1, TotalPages: 1, Start: 0, End: 5,
+				Url: "/archives.html", First: "/archives.html",
+				Last: "/archives.html"},
+		}},
+		{4, 2, "/archives.html", "/archives/", []Paginator{
+			{Page: 1, TotalPages: 2, Start: 0, End: 2,
+				Url: "/archives.html", First: "/archives.html",
+				Last: "/archives/page/2/", Next: "/archives/page/2/"},
+			{Page: 2, TotalPages: 2, Start: 2, End: 4,
+				Url: "/archives/page/2/", First: "/archives.html",
+				Last: "/archives/page/2/", Prev: "/archives.html"},
+		}},
+	}
+
+	for i, test := range tests {
+		result := Paginate(test.total, test.perPage, test.first, test.prefix)
+		if len(result) != len(test.expected) {
+			t.Errorf("(%d) expecting %d pages but got %d", i,
+				len(test.expected), len(result))
+			continue
+		}
+
+		for k, e := range test.expected {
+			r := result[k]
+			if r.Page != e.Page || r.TotalPages != e.TotalPages ||
+				r.Start != e.Start || r.End != e.End || r.Url != e.Url ||
+				r.First != e.First || r.Last != e.Last ||
+				r.Prev != e.Prev || r.Next != e.Next {
+				t.Errorf("(%d) page %d: expecting %+v but got %+v", i, k,
+					e, *r)
+			}
+		}
+	}
+}
+
+// TestFile tests the files the pages are written to.
+func TestFile(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"/", "index.html"},
+		{"/page/2/", "page/2/index.html"},
+		{"/archives.html", "archives.html"},
+		{"/tags/go/page/3/", "tags/go/page/3/index.html"},
+	}
+
+	for i, test := range tests {
+		p := &Paginator{Url: test.url}
+		if p.File() != test.expected {
+			t.Errorf("(%d) expecting '%s' but got '%s'", i, test.expected,
+				p.File())
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package rss
+
+import (
+	"bytes"
+	"encoding/xml"
+	"github.com/pyanfield/goblog/blogs"
+	"io/ioutil"
+	"path"
+	"time"
+)
+
+// atomFeed is the <feed> of an Atom 1.0 document.
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Lang     string      `xml:"xml:lang,attr,omitempty"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Links    []atomLink  `xml:"link"`
+	ID       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Author   *atomPerson `xml:"author,omitempty"`
+	Logo     string      `xml:"logo,omitempty"`
+	Rights   string      `xml:"rights,omitempty"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+// atomLink is a <link>.
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+// atomPerson is an <author>.
+type atomPerson struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email,omitempty"`
+}
+
+// atomText is a <summary> or <content> and its type.
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// atomCategory is a <category>.
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// atomEntry is the <entry> of a blog entry.
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	Links      []atomLink     `xml:"link"`
+	ID         string         `xml:"id"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+// MakeAtom creates a completed atom.xml Atom 1.0 document of the given
+// entries and puts it into the given directory. The feed and its
+// entries are described by the given Channel, whose Content says
+// whether the entries have a <content>. Each entry's id is a tag
+// URI that doesn't change when the site moves and its updated date
+// is the entry's Updated date.
+// 生成 atom.xml
+func MakeAtom(entries []*blogs.BlogEntry, ch Channel, dir string) error {
+	f := atomFeed{
+		Lang:     ch.Language,
+		Title:    ch.Title,
+		Subtitle: ch.Description,
+		Links: []atomLink{
+			{Href: ch.Link + "atom.xml", Rel: "self",
+				Type: "application/atom+xml"},
+			{Href: ch.Link, Rel: "alternate", Type: "text/html"},
+		},
+		ID:      ch.Link,
+		Updated: lastUpdated(entries).Format(time.RFC3339),
+		Logo:    ch.Image,
+		Rights:  ch.Copyright,
+	}
+	if ch.Author != "" {
+		f.Author = &atomPerson{Name: ch.Author, Email: ch.Email}
+	}
+
+	for _, entry := range entries {
+		e := atomEntry{
+			Title: entry.Title,
+			Links: []atomLink{
+				{Href: ch.Link + entry.Url, Rel: "alternate",
+					Type: "text/html"},
+			},
+			ID:        entryID(ch.Link, entry),
+			Published: entry.Created.Format(time.RFC3339),
+			Updated:   entry.Updated.Format(time.RFC3339),
+			Summary:   &atomText{Type: "text", Body: ch.summary(entry)},
+		}
+
+		// The feed's author is every entry's author unless it has its
+		// own.
+		if entry.Author != "" && entry.Author != ch.Author {
+			e.Author = &atomPerson{Name: entry.Author}
+		} else if f.Author == nil {
+			e.Author = &atomPerson{Name: ch.Title}
+		}
+
+		if content := ch.content(entry); content != "" {
+			e.Content = &atomText{Type: "html", Body: content}
+		}
+
+		for _, tag := range entry.Tags {
+			e.Categories = append(e.Categories, atomCategory{tag})
+		}
+
+		f.Entries = append(f.Entries, e)
+	}
+
+	out, err := xml.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write out the file.
+	sw := bytes.NewBufferString(xml.Header)
+	sw.Write(out)
+	sw.WriteString("\n")
+	return ioutil.WriteFile(path.Join(dir, "atom.xml"), sw.Bytes(), 0644)
+}
@@ -0,0 +1,138 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package rss
+
+import (
+	"github.com/pyanfield/goblog/blogs"
+	"github.com/pyanfield/goblog/config"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Channel is what the feeds say about the site itself.
+type Channel struct {
+	// Title is the name of the site.
+	Title string
+
+	// Link is the url of the site. It ends with a slash so the url of
+	// an entry is Link + its Url.
+	Link string
+
+	// Description is a short description of the site.
+	Description string
+
+	// Author and Email are who writes the site.
+	Author string
+	Email  string
+
+	// Language is the language code of the site (e.g. en-us).
+	Language string
+
+	// Copyright is the copyright notice of the site.
+	Copyright string
+
+	// Image is the full url of the logo of the site, or "".
+	Image string
+
+	// Content is how much of each entry is in the feeds, one of the
+	// Content constants. It's ContentSummary when it's "".
+	Content string
+
+	// Tag is the name of the tag the RSS feed is of, or "" for the
+	// feed of the whole site. The feed's title ends with it.
+	Tag string
+
+	// TagUrl is the url of the page of the Tag from the root of the
+	// site (e.g. tags/go/). It's the link of the feed.
+	TagUrl string
+}
+
+// NewChannel returns the Channel of the given site configuration. url
+// is the url of the site, which may come from a flag instead of the
+// configuration. An image given from the root of the site (e.g.
+// /logo.png) is made a full url.
+func NewChannel(site *config.Config, url string) Channel {
+	if url != "" && !strings.HasSuffix(url, "/") {
+		url += "/"
+	}
+
+	// The image may be given from the root of the site.
+	image := site.Image
+	if image != "" && !strings.Contains(image, "://") {
+		image = url + strings.TrimPrefix(image, "/")
+	}
+
+	return Channel{
+		Title:       site.Title,
+		Link:        url,
+		Description: site.Description,
+		Author:      site.Author,
+		Email:       site.Email,
+		Language:    site.Language,
+		Copyright:   site.Copyright,
+		Image:       image,
+		Content:     site.FeedContent,
+	}
+}
+
+// ValidLink returns true if link is a full url (e.g.
+// http://example.com/). The links and ids in the feeds are made from
+// it, so they can't do without one.
+func ValidLink(link string) bool {
+	u, err := url.Parse(link)
+	return err == nil && u.IsAbs() && u.Host != ""
+}
+
+// Feed is one of the feeds of the site. They are given to the
+// templates so the pages can link to them with <link rel="alternate">.
+type Feed struct {
+	// Title names the kind of feed (e.g. "Atom").
+	Title string
+
+	// Type is the MIME type of the feed.
+	Type string
+
+	// Url is the url of the feed from the root of the site.
+	Url string
+}
+
+// Feeds are the feeds goblog makes for the site.
+var Feeds = []Feed{
+	{"RSS", "application/rss+xml", "feed.rss"},
+	{"Atom", "application/atom+xml", "atom.xml"},
+	{"JSON Feed", "application/feed+json", "feed.json"},
+}
+
+// lastUpdated returns the newest Updated date of the given entries, or
+// the current time if there aren't any.
+func lastUpdated(entries []*blogs.BlogEntry) time.Time {
+	last := time.Time{}
+	for _, entry := range entries {
+		if entry.Updated.After(last) {
+			last = entry.Updated
+		}
+	}
+
+	if last.IsZero() {
+		return time.Now()
+	}
+
+	return last
+}
+
+// entryID returns a tag URI (RFC 4151) for the entry, e.g.
+// tag:example.com,2013-04-09:/2013/04/intro/. It doesn't change if the
+// site moves to https or another path, only if the entry's url does.
+// Without a host in the link the entry's full url is used.
+func entryID(link string, entry *blogs.BlogEntry) string {
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return link + entry.Url
+	}
+
+	return "tag:" + u.Hostname() + "," + entry.Created.Format("2006-01-02") +
+		":" + strings.TrimSuffix(u.Path, "/") + "/" + entry.Url
+}
@@ -0,0 +1,118 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package rss
+
+import (
+	"github.com/pyanfield/goblog/blogs"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// The values of Channel.Content. They say how much of each entry the
+// feeds carry.
+const (
+	// ContentSummary only puts a summary of each entry in the feeds:
+	// its Description or the start of its text if it doesn't have one.
+	ContentSummary = "summary"
+
+	// ContentExcerpt adds the HTML of the entry up to MoreSeparator, or
+	// its first paragraph if it doesn't have one.
+	ContentExcerpt = "excerpt"
+
+	// ContentFull adds all of the HTML of the entry.
+	ContentFull = "full"
+)
+
+// MoreSeparator marks the end of the excerpt of an entry. It's put in
+// the markdown on a line of its own.
+const MoreSeparator = "<!--more-->"
+
+// summaryLength is the most characters of the text of an entry used
+// as its summary.
+const summaryLength = 300
+
+// linkRegexp matches the links and image sources that are made
+// absolute.
+var linkRegexp = regexp.MustCompile(`(\s(?:href|src)=")([^"]*)(")`)
+
+// ValidContent returns true if content is one of the Content values
+// (or "", which is ContentSummary).
+func ValidContent(content string) bool {
+	switch content {
+	case "", ContentSummary, ContentExcerpt, ContentFull:
+		return true
+	}
+
+	return false
+}
+
+// summary returns the summary of the entry for the feeds.
+func (ch Channel) summary(entry *blogs.BlogEntry) string {
+	if entry.Description != "" {
+		return entry.Description
+	}
+
+	text := blogs.PlainText(excerpt(string(entry.Content)))
+	if utf8.RuneCountInString(text) <= summaryLength {
+		return text
+	}
+
+	return string([]rune(text)[:summaryLength]) + "…"
+}
+
+// content returns the HTML of the entry for the feeds, or "" when they
+// only carry a summary. The links in it are absolute so they work in
+// feed readers.
+func (ch Channel) content(entry *blogs.BlogEntry) string {
+	var content string
+	switch ch.Content {
+	case ContentExcerpt:
+		content = excerpt(string(entry.Content))
+	case ContentFull:
+		content = string(entry.Content)
+	default:
+		return ""
+	}
+
+	return absoluteURLs(content, ch.Link+entry.Url)
+}
+
+// excerpt returns the HTML up to MoreSeparator or the end of the first
+// paragraph.
+func excerpt(content string) string {
+	if i := strings.Index(content, MoreSeparator); i >= 0 {
+		return content[:i]
+	}
+
+	if i := strings.Index(content, "</p>"); i >= 0 {
+		return content[:i+len("</p>")]
+	}
+
+	return content
+}
+
+// absoluteURLs returns the HTML with the relative urls of its links and
+// images resolved against base, the url of the page the HTML is from.
+// It's left alone if base isn't a full url.
+func absoluteURLs(content, base string) string {
+	b, err := url.Parse(base)
+	if err != nil || !b.IsAbs() {
+		return content
+	}
+
+	return linkRegexp.ReplaceAllStringFunc(content, func(m string) string {
+		parts := linkRegexp.FindStringSubmatch(m)
+		ref, err := url.Parse(html.UnescapeString(parts[2]))
+		if err != nil {
+			return m
+		}
+
+		return parts[1] + html.EscapeString(b.ResolveReference(ref).String()) +
+			parts[3]
+	})
+}
@@ -0,0 +1,293 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package rss
+
+import (
+	"github.com/pyanfield/goblog/blogs"
+	"github.com/pyanfield/goblog/config"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// TestEntryID tests the ids of the entries in the Atom and JSON feeds.
+func TestEntryID(t *testing.T) {
+	entry := &blogs.BlogEntry{
+		Url:     "2013/04/intro/",
+		Created: time.Date(2013, 4, 9, 12, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		link     string
+		expected string
+	}{
+		{"http://example.com/", "tag:example.com,2013-04-09:/2013/04/intro/"},
+		{"https://example.com:8080/blog/",
+			"tag:example.com,2013-04-09:/blog/2013/04/intro/"},
+		{"", "2013/04/intro/"},
+	}
+
+	for i, test := range tests {
+		id := entryID(test.link, entry)
+		if id != test.expected {
+			t.Errorf("(%d) expecting '%s' but got '%s'", i, test.expected, id)
+		}
+	}
+}
+
+// TestMakeJSONFeed tests the JSON Feed made from a site and its
+// entries.
+func TestMakeJSONFeed(t *testing.T) {
+	site := &config.Config{Title: "Blog", Author: "Joshua"}
+	created := time.Date(2013, 4, 9, 12, 0, 0, 0, time.UTC)
+	entries := []*blogs.BlogEntry{
+		{Title: "Mine", Url: "mine.html", Author: "Joshua",
+			Created: created, Updated: created.Add(time.Hour)},
+		{Title: "Guest", Url: "guest.html", Author: "Guest",
+			Created: created, Updated: created, Tags: []string{"go"}},
+	}
+
+	f := makeJSONFeed(entries, NewChannel(site, "http://example.com"))
+	if f.Version != jsonFeedVersion || f.FeedURL != "http://example.com/feed.json" ||
+		len(f.Authors) != 1 || f.Authors[0].Name != "Joshua" {
+		t.Fatalf("unexpected feed %+v", f)
+	}
+
+	if len(f.Items) != 2 {
+		t.Fatalf("expecting 2 items but got %d", len(f.Items))
+	}
+
+	tests := []struct {
+		url, modified string
+		authors       int
+	}{
+		{"http://example.com/mine.html", "2013-04-09T13:00:00Z", 0},
+		{"http://example.com/guest.html", "2013-04-09T12:00:00Z", 1},
+	}
+
+	for i, test := range tests {
+		item := f.Items[i]
+		if item.URL != test.url || item.DateModified != test.modified ||
+			len(item.Authors) != test.authors {
+			t.Errorf("(%d) expecting %s, %s, %d authors but got %+v", i,
+				test.url, test.modified, test.authors, item)
+		}
+	}
+}
+
+// TestContent tests the summaries and the HTML of the entries in the
+// feeds.
+func TestContent(t *testing.T) {
+	entry := &blogs.BlogEntry{
+		Url: "2013/04/intro/",
+		Content: `<p>See <a href="../old/">this</a> &amp; <img src="/a.png">.</p>
+<!--more-->
+<p><a href="http://go.dev/">Go</a> <a href="#notes">notes</a></p>`,
+	}
+	described := &blogs.BlogEntry{Description: "Hi", Content: entry.Content}
+
+	tests := []struct {
+		content, link    string
+		entry            *blogs.BlogEntry
+		summary, encoded string
+	}{
+		{ContentSummary, "http://example.com/", entry, "See this & .", ""},
+		{"", "http://example.com/", described, "Hi", ""},
+		{ContentExcerpt, "http://example.com/blog/", entry, "See this & .",
+			`<p>See <a href="http://example.com/blog/2013/04/old/">this</a> &amp; ` +
+				`<img src="http://example.com/a.png">.</p>` + "\n"},
+		{ContentFull, "http://example.com/", entry, "See this & .",
+			`<p>See <a href="http://example.com/2013/04/old/">this</a> &amp; ` +
+				`<img src="http://example.com/a.png">.</p>` + "\n<!--more-->\n" +
+				`<p><a href="http://go.dev/">Go</a> ` +
+				`<a href="http://example.com/2013/04/intro/#notes">notes</a></p>`},
+		{ContentFull, "", entry, "See this & .", string(entry.Content)},
+	}
+
+	for i, test := range tests {
+		ch := Channel{Link: test.link, Content: test.content}
+		if s := ch.summary(test.entry); s != test.summary {
+			t.Errorf("(%d) expecting summary %q but got %q", i, test.summary, s)
+		}
+
+		if c := ch.content(test.entry); c != test.encoded {
+			t.Errorf("(%d) expecting content %q but got %q", i, test.encoded, c)
+		}
+	}
+}
+
+// TestMakeChannel tests the <channel> made from the site configuration
+// and from channel.rss.
+func TestMakeChannel(t *testing.T) {
+	site := &config.Config{Title: "Blog", Author: "Joshua",
+		Email: "j@example.com", Copyright: "(c) 2013", Image: "/logo.png"}
+	ch := NewChannel(site, "http://example.com/blog")
+
+	c := makeChannel(ch, "")
+	if c.Title != "Blog" || c.Link != "http://example.com/blog/" ||
+		c.Copyright != "(c) 2013" || c.ManagingEditor != "j@example.com (Joshua)" {
+		t.Errorf("unexpected channel %+v", c)
+	}
+	if c.Image == nil || c.Image.URL != "http://example.com/blog/logo.png" {
+		t.Errorf("unexpected image %+v", c.Image)
+	}
+	if _, err := time.Parse(time.RFC1123Z, c.LastBuildDate); err != nil {
+		t.Errorf("expecting an RFC 1123 date but got %s", c.LastBuildDate)
+	}
+
+	c = makeChannel(ch, "<title>Mine</title>")
+	if c.Title != "" || c.Image != nil || c.Inner != "<title>Mine</title>" {
+		t.Errorf("expecting only channel.rss but got %+v", c)
+	}
+}
+
+// TestMakeTagChannel makes sure the feed of a tag is named after it
+// and links to its page, with or without channel.rss.
+func TestMakeTagChannel(t *testing.T) {
+	site := &config.Config{Title: "Blog", Image: "/logo.png"}
+	ch := NewChannel(site, "http://example.com/")
+	ch.Tag, ch.TagUrl = "Go & C", "tags/go-c/"
+
+	c := makeChannel(ch, "")
+	if c.Title != "Blog - Go & C" || c.Link != "http://example.com/tags/go-c/" {
+		t.Errorf("unexpected channel %+v", c)
+	}
+
+	tests := []struct {
+		override string
+		expected string
+	}{
+		{"<title>Mine</title>\n<link>http://mine.com</link>",
+			"<title>Mine - Go &amp; C</title>\n" +
+				"<link>http://mine.com/tags/go-c/</link>"},
+		{"<image><title>Logo</title><link>http://mine.com/</link></image>\n" +
+			"<title>Mine</title><link>http://mine.com/</link>",
+			"<image><title>Logo</title><link>http://mine.com/</link></image>\n" +
+				"<title>Mine - Go &amp; C</title>" +
+				"<link>http://mine.com/tags/go-c/</link>"},
+		{"<description>No title</description>",
+			"<description>No title</description>"},
+	}
+
+	for i, test := range tests {
+		c := makeChannel(ch, test.override)
+		if c.Inner != test.expected {
+			t.Errorf("(%d) expecting %q but got %q", i, test.expected, c.Inner)
+		}
+	}
+}
+
+// TestMakePodcast makes sure only the entries with an enclosure are
+// episodes, that their file names are escaped in the urls and that
+// the show falls back to the site's values.
+func TestMakePodcast(t *testing.T) {
+	dir, err := ioutil.TempDir("", "podcast")
+	if err != nil {
+		t.Fatalf("making temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	site := &config.Config{Title: "Blog", Author: "Joshua",
+		Email: "j@example.com"}
+	created := time.Date(2013, 4, 9, 12, 0, 0, 0, time.UTC)
+	entries := []*blogs.BlogEntry{
+		{Title: "Episode", Url: "episode.html", Created: created,
+			Enclosure: &blogs.Enclosure{File: "episodes/01.mp3",
+				Type: "audio/mpeg", Duration: "34:12", Episode: 1,
+				Length: 1234}},
+		{Title: "Post", Url: "post.html", Created: created},
+		{Title: "Bonus", Url: "bonus.html", Created: created,
+			Enclosure: &blogs.Enclosure{File: "episodes/bonus #1.mp3",
+				Type: "audio/mpeg", Length: 99}},
+	}
+
+	err = MakePodcast(entries, NewChannel(site, "http://example.com"),
+		config.Podcast{Title: "Show", Categories: []string{"Technology"}}, dir)
+	if err != nil {
+		t.Fatalf("making podcast: %s", err)
+	}
+
+	out, err := ioutil.ReadFile(path.Join(dir, "podcast.rss"))
+	if err != nil {
+		t.Fatalf("reading podcast.rss: %s", err)
+	}
+
+	// The iTunes tags can't be read back by encoding/xml, so look for
+	// them in the document.
+	doc := string(out)
+	tests := []string{
+		`xmlns:itunes="` + itunesNS + `"`,
+		"<title>Show</title>",
+		"<itunes:author>Joshua</itunes:author>",
+		"<itunes:email>j@example.com</itunes:email>",
+		`<itunes:category text="Technology"></itunes:category>`,
+		"<itunes:explicit>false</itunes:explicit>",
+		`<enclosure url="http://example.com/episodes/01.mp3" length="1234" type="audio/mpeg"></enclosure>`,
+		"<itunes:duration>34:12</itunes:duration>",
+		"<itunes:episode>1</itunes:episode>",
+		"<pubDate>" + entries[0].PubDate() + "</pubDate>",
+		`<enclosure url="http://example.com/episodes/bonus%20%231.mp3" length="99" type="audio/mpeg"></enclosure>`,
+	}
+
+	for i, test := range tests {
+		if !strings.Contains(doc, test) {
+			t.Errorf("(%d) expecting %s in %s", i, test, doc)
+		}
+	}
+
+	if n := strings.Count(doc, "<item>"); n != 2 {
+		t.Errorf("expecting 2 episodes but got %d", n)
+	}
+}
+
+// TestValidLink tests the site urls the feeds accept.
+func TestValidLink(t *testing.T) {
+	tests := []struct {
+		link     string
+		expected bool
+	}{
+		{"http://example.com/", true},
+		{"https://example.com/blog", true},
+		{"", false},
+		{"/blog/", false},
+		{"example.com", false},
+	}
+
+	for i, test := range tests {
+		if v := ValidLink(test.link); v != test.expected {
+			t.Errorf("(%d) expecting %v for %q but got %v", i, test.expected,
+				test.link, v)
+		}
+	}
+}
+
+// TestChannelLink tests the <link> taken from channel.rss for a site
+// without a url.
+func TestChannelLink(t *testing.T) {
+	tests := []struct {
+		channel  string
+		expected string
+	}{
+		{"<title>Blog</title>\n<link>http://example.com/</link>",
+			"http://example.com/"},
+		{"<link> http://example.com/ </link>", "http://example.com/"},
+		{"<title>Blog</title>", ""},
+	}
+
+	for i, test := range tests {
+		tmplts := fstest.MapFS{"channel.rss": {Data: []byte(test.channel)}}
+		if link := ChannelLink(tmplts); link != test.expected {
+			t.Errorf("(%d) expecting %q but got %q", i, test.expected, link)
+		}
+	}
+
+	if link := ChannelLink(fstest.MapFS{}); link != "" {
+		t.Errorf("expecting no link without channel.rss but got %q", link)
+	}
+}
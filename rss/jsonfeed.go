@@ -0,0 +1,114 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package rss
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/pyanfield/goblog/blogs"
+	"io/ioutil"
+	"path"
+	"time"
+)
+
+// jsonFeedVersion is the version of the JSON Feed spec of feed.json.
+const jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+// jsonFeed is a JSON Feed document.
+type jsonFeed struct {
+	Version     string       `json:"version"`
+	Title       string       `json:"title"`
+	HomePageURL string       `json:"home_page_url,omitempty"`
+	FeedURL     string       `json:"feed_url,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Language    string       `json:"language,omitempty"`
+	Icon        string       `json:"icon,omitempty"`
+	Authors     []jsonAuthor `json:"authors,omitempty"`
+	Items       []jsonItem   `json:"items"`
+}
+
+// jsonAuthor is an author of the feed or of an item.
+type jsonAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// jsonItem is the item of a blog entry.
+type jsonItem struct {
+	ID            string       `json:"id"`
+	URL           string       `json:"url"`
+	Title         string       `json:"title"`
+	ContentHTML   string       `json:"content_html,omitempty"`
+	ContentText   string       `json:"content_text,omitempty"`
+	Summary       string       `json:"summary,omitempty"`
+	DatePublished string       `json:"date_published"`
+	DateModified  string       `json:"date_modified"`
+	Authors       []jsonAuthor `json:"authors,omitempty"`
+	Tags          []string     `json:"tags,omitempty"`
+}
+
+// MakeJSONFeed creates a completed feed.json JSON Feed 1.1 document of
+// the given entries and puts it into the given directory. The feed is
+// described by the given Channel. The items have the same ids as the
+// entries of MakeAtom and, like them, the HTML of the entries if the
+// Channel's Content asks for it.
+// 生成 feed.json
+func MakeJSONFeed(entries []*blogs.BlogEntry, ch Channel, dir string) error {
+	// The HTML is kept as it is instead of as \u003c escapes.
+	sw := new(bytes.Buffer)
+	enc := json.NewEncoder(sw)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(makeJSONFeed(entries, ch))
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path.Join(dir, "feed.json"), sw.Bytes(), 0644)
+}
+
+// makeJSONFeed returns the JSON Feed of the given entries.
+func makeJSONFeed(entries []*blogs.BlogEntry, ch Channel) jsonFeed {
+	f := jsonFeed{
+		Version:     jsonFeedVersion,
+		Title:       ch.Title,
+		HomePageURL: ch.Link,
+		FeedURL:     ch.Link + "feed.json",
+		Description: ch.Description,
+		Language:    ch.Language,
+		Icon:        ch.Image,
+		Items:       []jsonItem{},
+	}
+	if ch.Link == "" {
+		f.FeedURL = ""
+	}
+	if ch.Author != "" {
+		f.Authors = []jsonAuthor{{Name: ch.Author}}
+	}
+
+	for _, entry := range entries {
+		item := jsonItem{
+			ID:            entryID(ch.Link, entry),
+			URL:           ch.Link + entry.Url,
+			Title:         entry.Title,
+			ContentHTML:   ch.content(entry),
+			Summary:       entry.Description,
+			DatePublished: entry.Created.Format(time.RFC3339),
+			DateModified:  entry.Updated.Format(time.RFC3339),
+			Tags:          entry.Tags,
+		}
+		if item.ContentHTML == "" {
+			// Every item needs some content.
+			item.ContentText = ch.summary(entry)
+		}
+		if entry.Author != "" && entry.Author != ch.Author {
+			item.Authors = []jsonAuthor{{Name: entry.Author}}
+		}
+
+		f.Items = append(f.Items, item)
+	}
+
+	return f
+}
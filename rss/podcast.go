@@ -0,0 +1,176 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package rss
+
+import (
+	"bytes"
+	"encoding/xml"
+	"github.com/pyanfield/goblog/blogs"
+	"github.com/pyanfield/goblog/config"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// itunesNS is the namespace of the iTunes podcast tags.
+const itunesNS = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+
+// podcastFeed is the <rss> document of a podcast.
+type podcastFeed struct {
+	XMLName  xml.Name       `xml:"rss"`
+	Version  string         `xml:"version,attr"`
+	ItunesNS string         `xml:"xmlns:itunes,attr"`
+	Channel  podcastChannel `xml:"channel"`
+}
+
+// podcastChannel is the <channel> of a podcast.
+type podcastChannel struct {
+	Title         string           `xml:"title"`
+	Link          string           `xml:"link"`
+	Description   string           `xml:"description"`
+	Language      string           `xml:"language,omitempty"`
+	Copyright     string           `xml:"copyright,omitempty"`
+	LastBuildDate string           `xml:"lastBuildDate"`
+	Author        string           `xml:"itunes:author,omitempty"`
+	Summary       string           `xml:"itunes:summary,omitempty"`
+	Owner         *itunesOwner     `xml:"itunes:owner,omitempty"`
+	Image         *itunesImage     `xml:"itunes:image,omitempty"`
+	Categories    []itunesCategory `xml:"itunes:category"`
+	Explicit      string           `xml:"itunes:explicit"`
+	Items         []podcastItem    `xml:"item"`
+}
+
+// itunesOwner is the <itunes:owner> of a podcast.
+type itunesOwner struct {
+	Name  string `xml:"itunes:name,omitempty"`
+	Email string `xml:"itunes:email"`
+}
+
+// itunesImage is an <itunes:image>.
+type itunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+// itunesCategory is an <itunes:category>.
+type itunesCategory struct {
+	Text string `xml:"text,attr"`
+}
+
+// podcastItem is the <item> of an episode.
+type podcastItem struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	GUID        guid      `xml:"guid"`
+	Description string    `xml:"description"`
+	PubDate     string    `xml:"pubDate"`
+	Enclosure   enclosure `xml:"enclosure"`
+	Duration    string    `xml:"itunes:duration,omitempty"`
+	Episode     int       `xml:"itunes:episode,omitempty"`
+	Author      string    `xml:"itunes:author,omitempty"`
+}
+
+// guid is the <guid> of an episode. It's the same id as the Atom and
+// JSON feeds use, which isn't a link.
+type guid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	ID          string `xml:",chardata"`
+}
+
+// enclosure is the <enclosure> of an episode.
+type enclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// MakePodcast creates a completed podcast.rss document of the entries
+// that have an Enclosure and puts it into the given directory. It's an
+// RSS 2.0 feed with the iTunes tags podcast apps expect. The show is
+// described by the given Podcast; the values it doesn't have come from
+// the Channel. The Length of each Enclosure must already be known.
+// 生成播客使用的 podcast.rss，只包含有 enclosure 的博客
+func MakePodcast(entries []*blogs.BlogEntry, ch Channel, p config.Podcast,
+	dir string) error {
+
+	c := podcastChannel{
+		Title:         firstOf(p.Title, ch.Title),
+		Link:          ch.Link,
+		Description:   firstOf(p.Description, ch.Description),
+		Language:      ch.Language,
+		Copyright:     ch.Copyright,
+		LastBuildDate: time.Now().Format(time.RFC1123Z),
+		Author:        firstOf(p.Author, ch.Author),
+		Summary:       firstOf(p.Description, ch.Description),
+		Explicit:      strconv.FormatBool(p.Explicit),
+	}
+
+	if email := firstOf(p.Email, ch.Email); email != "" {
+		c.Owner = &itunesOwner{Name: c.Author, Email: email}
+	}
+
+	image := ch.Image
+	if p.Image != "" {
+		image = NewChannel(&config.Config{Image: p.Image}, ch.Link).Image
+	}
+	if image != "" {
+		c.Image = &itunesImage{image}
+	}
+
+	for _, category := range p.Categories {
+		c.Categories = append(c.Categories, itunesCategory{category})
+	}
+
+	for _, entry := range entries {
+		e := entry.Enclosure
+		if e == nil {
+			continue
+		}
+
+		c.Items = append(c.Items, podcastItem{
+			Title:       entry.Title,
+			Link:        ch.Link + entry.Url,
+			GUID:        guid{ID: entryID(ch.Link, entry)},
+			Description: ch.summary(entry),
+			PubDate:     entry.PubDate(),
+			Enclosure: enclosure{
+				URL:    ch.Link + (&url.URL{Path: e.File}).EscapedPath(),
+				Length: e.Length,
+				Type:   e.Type,
+			},
+			Duration: e.Duration,
+			Episode:  e.Episode,
+			Author:   entry.Author,
+		})
+	}
+
+	out, err := xml.MarshalIndent(podcastFeed{
+		Version:  "2.0",
+		ItunesNS: itunesNS,
+		Channel:  c,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write out the file.
+	sw := bytes.NewBufferString(xml.Header)
+	sw.Write(out)
+	sw.WriteString("\n")
+	return ioutil.WriteFile(path.Join(dir, "podcast.rss"), sw.Bytes(), 0644)
+}
+
+// firstOf returns the first of the values that isn't empty.
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -3,82 +3,301 @@
 // the LICENSE file.
 
 // Package rss contains structures, methods and functions for
-// making an rss feed.
+// making the feeds of the site: RSS 2.0, Atom 1.0 and JSON Feed 1.1.
 package rss
 
 import (
 	"bytes"
-	"fmt"
+	"encoding/xml"
+	"errors"
 	"github.com/pyanfield/goblog/blogs"
+	"html"
+	"io/fs"
 	"io/ioutil"
 	"path"
 	"regexp"
+	"strings"
 	"text/template"
 	"time"
 )
 
-var sfeed = `<?xml version="1.0" encoding="UTF-8" ?>
-<rss version="2.0">
-  <channel>
-    <lastBuildDate>{{.CreateDate}}</lastBuildDate> 
-{{.ChannelContent}} 
-{{range .Blogs}}    <item>
-      <title>{{.Title}}</title>
-      <link>%s{{.Url}}</link>
-      <description>{{.Description}}</description>
-      <pubDate>{{.PubDate}}</pubDate>
-{{range .Tags}}      <category>{{.}}</category>
-{{end}}    </item>
-{{end}}
-  </channel>
-</rss>`
+// contentNS is the namespace of <content:encoded>.
+const contentNS = "http://purl.org/rss/1.0/modules/content/"
+
+// feed is the <rss> document.
+type feed struct {
+	XMLName   xml.Name `xml:"rss"`
+	Version   string   `xml:"version,attr"`
+	ContentNS string   `xml:"xmlns:content,attr"`
+	Channel   channel  `xml:"channel"`
+}
+
+// channel is the <channel> of the feed. Its values come from the
+// Channel unless there is a channel.rss. The values from channel.rss
+// and the <item>s are already XML, so they are written as they are.
+type channel struct {
+	Title          string `xml:"title,omitempty"`
+	Link           string `xml:"link,omitempty"`
+	Description    string `xml:"description,omitempty"`
+	Language       string `xml:"language,omitempty"`
+	Copyright      string `xml:"copyright,omitempty"`
+	ManagingEditor string `xml:"managingEditor,omitempty"`
+	LastBuildDate  string `xml:"lastBuildDate"`
+	Image          *image `xml:"image,omitempty"`
+	Inner          string `xml:",innerxml"`
+}
+
+// image is the <image> of the channel.
+type image struct {
+	URL   string `xml:"url"`
+	Title string `xml:"title"`
+	Link  string `xml:"link"`
+}
+
+// item is the default <item> for a blog entry. All of the values are
+// escaped when it's marshaled.
+type item struct {
+	XMLName     xml.Name `xml:"item"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+	Content     *cdata   `xml:"content:encoded,omitempty"`
+}
+
+// cdata is text that is written as a CDATA section.
+type cdata struct {
+	Text string `xml:",cdata"`
+}
+
+// Item is the value passed to the item template for each blog
+// entry. All of the BlogEntry values are available (including
+// .Params) as well as .SiteUrl, the url of the site, .Summary, the
+// Description or the start of the text of the entry, and .FeedContent,
+// the HTML for <content:encoded> with absolute urls ("" unless the
+// feed_content setting asks for it).
+type Item struct {
+	*blogs.BlogEntry
+	SiteUrl     string
+	Summary     string
+	FeedContent string
+}
+
+// funcs are the functions available to item.rss. The xml function
+// escapes a value so it can be used as text or in an attribute.
+var funcs = template.FuncMap{
+	"xml": escape,
+}
+
+// escape returns s with the XML special characters escaped.
+func escape(s string) (string, error) {
+	buf := new(bytes.Buffer)
+	err := xml.EscapeText(buf, []byte(s))
+	return buf.String(), err
+}
 
 // MakeRss creates a completed feed.rss xml document and puts it into
-// the given directory. It uses the template from channel.rss to
-// populated the channel values except for the <item>s.
-func MakeRss(entries []*blogs.BlogEntry, url, tdir, dir string) error {
+// the given directory. The channel values are made from the given
+// Channel. If there is a channel.rss in the templates, its contents
+// are used for the channel values instead, except for the <item>s.
+// The links of the items start with the Link of the Channel, and its
+// Content says whether the items have a <content:encoded>. Each
+// <item> is generated from item.rss if it exists. Otherwise, the items
+// are made by goblog and every value in them is escaped. The values
+// given to item.rss are not escaped, so it should use the xml function
+// (e.g. {{.Title | xml}}).
+// 生成 feed.rss，默认的 <item> 中所有的值都会被转义
+func MakeRss(entries []*blogs.BlogEntry, ch Channel, tmplts fs.FS,
+	dir string) error {
 
-	// Get the channel data.
-	channelContent, err := ioutil.ReadFile(path.Join(tdir, "channel.rss"))
-	if err != nil {
+	// Get the channel data if the templates override it.
+	channelContent, err := fs.ReadFile(tmplts, "channel.rss")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		return err
 	}
 
-	// Make the feed template.
-	// We need to get the URL to for the <links>
-	if url == "" {
-		// Try to get it from the channel.rss <link>
-		re := regexp.MustCompile("<link>([^<]*)</link>")
-		found := re.FindSubmatch(channelContent)
-		if len(found) > 1 {
-			url = string(found[1])
-		}
+	// The links need the url of the site. Without one, the <link> of
+	// channel.rss is used.
+	if ch.Link == "" {
+		ch.Link = channelLink(channelContent)
 	}
 
-	feed := fmt.Sprintf(sfeed, url)
-
-	var tmplt = template.Must(template.New("rss").Parse(feed))
+	// Make the <item>s.
+	items, err := makeItems(entries, ch, tmplts)
+	if err != nil {
+		return err
+	}
 
-	// Make the data that will be passed to the templater.
-	data := struct {
-		Blogs          []*blogs.BlogEntry
-		CreateDate     string
-		ChannelContent string
-	}{
-		Blogs:          entries,
-		CreateDate:     time.Now().Format(time.RFC822),
-		ChannelContent: string(channelContent),
+	f := feed{
+		Version:   "2.0",
+		ContentNS: contentNS,
+		Channel:   makeChannel(ch, string(channelContent)),
 	}
+	f.Channel.Inner = "\n" + strings.TrimRight(f.Channel.Inner+items, " \n")
 
-	// Perform the templating
-	sw := new(bytes.Buffer)
-	err = tmplt.Execute(sw, data)
+	out, err := xml.MarshalIndent(f, "", "  ")
 	if err != nil {
 		return err
 	}
 
 	// Write out the file.
+	sw := bytes.NewBufferString(xml.Header)
+	sw.Write(out)
 	err = ioutil.WriteFile(path.Join(dir, "feed.rss"), sw.Bytes(), 0644)
 
 	return err
 }
+
+// ChannelLink returns the <link> of the channel.rss in the given
+// templates, or "" if there isn't one. Sites that have it there don't
+// need a url to have feeds.
+func ChannelLink(tmplts fs.FS) string {
+	content, err := fs.ReadFile(tmplts, "channel.rss")
+	if err != nil {
+		return ""
+	}
+
+	return channelLink(content)
+}
+
+// channelLinkRegexp finds the <link> of a channel.rss.
+var channelLinkRegexp = regexp.MustCompile("<link>([^<]*)</link>")
+
+// channelLink returns the <link> in the given channel.rss contents.
+func channelLink(content []byte) string {
+	found := channelLinkRegexp.FindSubmatch(content)
+	if len(found) > 1 {
+		return strings.TrimSpace(string(found[1]))
+	}
+
+	return ""
+}
+
+// makeChannel returns the <channel> without its <item>s. When the
+// contents of channel.rss are given, they are the channel instead of
+// the values of the Channel. The feed of a Tag is named after it and
+// links to its page either way.
+func makeChannel(ch Channel, override string) channel {
+	c := channel{LastBuildDate: time.Now().Format(time.RFC1123Z)}
+	if override != "" {
+		c.Inner = override
+		if ch.Tag != "" {
+			c.Inner = replaceElement(c.Inner, "title", func(s string) string {
+				return tagTitle(s, html.EscapeString(ch.Tag))
+			})
+			c.Inner = replaceElement(c.Inner, "link", func(s string) string {
+				link := strings.TrimSpace(s)
+				if !strings.HasSuffix(link, "/") {
+					link += "/"
+				}
+				return link + html.EscapeString(ch.TagUrl)
+			})
+		}
+		return c
+	}
+
+	c.Title = tagTitle(ch.Title, ch.Tag)
+	c.Link = ch.Link + ch.TagUrl
+	c.Description = ch.Description
+	c.Language = ch.Language
+	c.Copyright = ch.Copyright
+	if ch.Email != "" {
+		c.ManagingEditor = ch.Email
+		if ch.Author != "" {
+			c.ManagingEditor += " (" + ch.Author + ")"
+		}
+	}
+	if ch.Image != "" {
+		c.Image = &image{URL: ch.Image, Title: ch.Title, Link: ch.Link}
+	}
+
+	return c
+}
+
+// tagTitle returns the title of the feed of the given tag on the site
+// with the given title.
+func tagTitle(title, tag string) string {
+	if tag == "" {
+		return title
+	}
+
+	return strings.TrimPrefix(title+" - "+tag, " - ")
+}
+
+// imageRegexp matches the <image> of channel.rss, whose <title> and
+// <link> aren't the channel's.
+var imageRegexp = regexp.MustCompile(`(?s)<image>.*?</image>`)
+
+// replaceElement returns the XML with the text of the first of its
+// <name> elements that isn't in its <image> replaced with the result
+// of f.
+func replaceElement(inner, name string, f func(string) string) string {
+	images := imageRegexp.FindAllStringIndex(inner, -1)
+	re := regexp.MustCompile(`(?s)<` + name + `>(.*?)</` + name + `>`)
+
+	for _, m := range re.FindAllStringSubmatchIndex(inner, -1) {
+		inImage := false
+		for _, img := range images {
+			inImage = inImage || (m[0] >= img[0] && m[0] < img[1])
+		}
+
+		if !inImage {
+			return inner[:m[2]] + f(inner[m[2]:m[3]]) + inner[m[3]:]
+		}
+	}
+
+	return inner
+}
+
+// makeItems returns the <item>s for the given entries. They come from
+// the item.rss template if there is one.
+func makeItems(entries []*blogs.BlogEntry, ch Channel,
+	tmplts fs.FS) (string, error) {
+
+	sw := new(bytes.Buffer)
+
+	itemContent, err := fs.ReadFile(tmplts, "item.rss")
+	if errors.Is(err, fs.ErrNotExist) {
+		for _, entry := range entries {
+			i := item{
+				Title:       entry.Title,
+				Link:        ch.Link + entry.Url,
+				Description: ch.summary(entry),
+				PubDate:     entry.PubDate(),
+				Categories:  entry.Tags,
+			}
+			if content := ch.content(entry); content != "" {
+				i.Content = &cdata{content}
+			}
+
+			out, err := xml.MarshalIndent(i, "    ", "  ")
+			if err != nil {
+				return "", err
+			}
+
+			sw.Write(out)
+			sw.WriteString("\n")
+		}
+
+		return sw.String(), nil
+	} else if err != nil {
+		return "", err
+	}
+
+	tmplt, err := template.New("item").Funcs(funcs).Parse(string(itemContent))
+	if err != nil {
+		return "", err
+	}
+
+	// Wrap each entry so the item template knows the site url.
+	for _, entry := range entries {
+		err = tmplt.Execute(sw, Item{entry, ch.Link, ch.summary(entry),
+			ch.content(entry)})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return sw.String(), nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"github.com/pyanfield/goblog/skeleton"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// runInit creates a new workspace in the given directory (or the
+// working directory, or the current one) from the skeleton. The
+// default theme is compiled in, so the workspace builds right away.
+// The templates and static directories start out empty for the files
+// that override the theme's. Existing files are never overwritten.
+// 在指定的文件夹下创建一个新的博客工作区，包括可以直接使用的模版，样式和第一篇博客
+func runInit(args []string) error {
+	dir := "."
+	if WorkingDir != "" {
+		dir = WorkingDir
+	}
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	err := os.MkdirAll(dir, 0750)
+	if err != nil {
+		return err
+	}
+
+	files := skeleton.Files()
+	err = fs.WalkDir(files, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		dest := filepath.Join(dir, filepath.FromSlash(p))
+		if d.IsDir() {
+			return os.MkdirAll(dest, 0750)
+		}
+
+		// Leave the user's own files alone.
+		if _, err := os.Stat(dest); err == nil {
+			fmt.Println("exists, skipping", dest)
+			return nil
+		}
+
+		contents, err := fs.ReadFile(files, p)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("created", dest)
+		return ioutil.WriteFile(dest, contents, 0644)
+	})
+	if err != nil {
+		return err
+	}
+
+	// The output directory is generated, but make it so it's obvious
+	// where the site will go. Make the directories for overriding the
+	// theme too.
+	for _, d := range []string{"public", "pages", "templates", "static"} {
+		err = os.MkdirAll(path.Join(dir, d), 0750)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("\nYour workspace is ready. Build it with\n\n"+
+		"    cd %s && goblog build\n", dir)
+	return nil
+}
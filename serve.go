@@ -0,0 +1,225 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/pyanfield/goblog/config"
+	"github.com/pyanfield/goblog/server"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// rebuildDelay is how long to wait after a change before rebuilding.
+// Editors usually write several events for a single save.
+const rebuildDelay = 200 * time.Millisecond
+
+// serve builds the site into a temporary directory, serves it on Addr
+// and rebuilds it whenever something in the blog, page, template,
+// static or theme directories or the configuration file changes. Open
+// browsers are told to reload after each rebuild.
+// 生成站点到一个临时文件夹并通过 HTTP 提供预览，文件修改之后自动重新生成并刷新浏览器
+func serve() error {
+	// Build into a temporary directory so the real output is left
+	// alone.
+	dir, err := ioutil.TempDir("", "goblog")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	previewSettings(dir)
+
+	// The first build. Errors are shown but we keep serving so they
+	// can be fixed.
+	err = buildSite()
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// The configuration file is watched through the working directory,
+	// so it's found again when an editor replaces it.
+	err = watcher.Add(WorkingDir)
+	if err != nil {
+		return err
+	}
+	err = watchDirs(watcher)
+	if err != nil {
+		return err
+	}
+
+	srv := server.New(dir)
+	go rebuildOnChange(watcher, srv, dir)
+
+	// Serve until we are interrupted.
+	errs := make(chan error, 1)
+	go func() {
+		errs <- http.ListenAndServe(Addr, srv)
+	}()
+	fmt.Printf("serving on http://%s/ (press Ctrl+C to stop)\n", Addr)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	select {
+	case err = <-errs:
+		return err
+	case <-interrupt:
+		return nil
+	}
+}
+
+// previewSettings changes the settings to preview the site in the
+// given directory. The sitemap and the feeds need a full url, so a site
+// without one is previewed at the address it's served on.
+func previewSettings(dir string) {
+	OutputDir = dir
+
+	if URL == "" {
+		host := Addr
+		if strings.HasPrefix(host, ":") {
+			host = "localhost" + host
+		}
+		URL = "http://" + host + "/"
+		Site.URL = URL
+	}
+}
+
+// watchDirs adds the blog, page, template, static and theme
+// directories to the watcher.
+func watchDirs(watcher *fsnotify.Watcher) error {
+	dirs := []string{BlogDir, PageDir, TemplateDir, StaticDir}
+	if ThemeDir != "" {
+		dirs = append(dirs, ThemeDir)
+	}
+	for _, d := range dirs {
+		err := watchRecursively(watcher, d)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reloadConfig loads the configuration again after the file changed,
+// along with the directories and the theme it names, and watches them.
+// The site is still previewed in the given directory.
+func reloadConfig(watcher *fsnotify.Watcher, dir string) error {
+	err := setupDirectories()
+	if err != nil {
+		return err
+	}
+	previewSettings(dir)
+
+	return watchDirs(watcher)
+}
+
+// rebuildOnChange rebuilds the site and reloads the browsers after the
+// watcher reports changes. Changes that come in quick succession only
+// cause one rebuild. The configuration is loaded again first if its
+// file changed. The site is built into the given directory.
+func rebuildOnChange(watcher *fsnotify.Watcher, srv *server.Server,
+	dir string) {
+
+	timer := time.NewTimer(rebuildDelay)
+	timer.Stop()
+
+	reload := false
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			// Only the configuration file matters in the working
+			// directory; the rest of it is watched on its own.
+			if filepath.Dir(event.Name) == filepath.Clean(WorkingDir) {
+				if !isConfigFile(event.Name) {
+					continue
+				}
+				reload = true
+			}
+
+			// New directories need watching too.
+			if event.Op&fsnotify.Create != 0 {
+				if fi, err := os.Stat(event.Name); err == nil && fi.IsDir() {
+					watchRecursively(watcher, event.Name)
+				}
+			}
+
+			timer.Reset(rebuildDelay)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			fmt.Println("watching files:", err)
+
+		case <-timer.C:
+			if reload {
+				reload = false
+				err := reloadConfig(watcher, dir)
+				if err != nil {
+					fmt.Println("loading configuration:", err)
+					continue
+				}
+			}
+
+			start := time.Now()
+			err := buildSite()
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+
+			fmt.Printf("rebuilt in %s\n", time.Since(start))
+			srv.Reload()
+		}
+	}
+}
+
+// isConfigFile returns true if the given file is the ConfigFile or, if
+// there isn't one yet, one of the configuration files goblog reads.
+func isConfigFile(file string) bool {
+	if ConfigFile != "" {
+		return filepath.Clean(file) == filepath.Clean(ConfigFile)
+	}
+
+	for _, name := range config.FileNames {
+		if filepath.Base(file) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// watchRecursively adds dir and all of its sub-directories to the
+// watcher.
+func watchRecursively(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if fi.IsDir() {
+			return watcher.Add(p)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,154 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+// Package server serves a generated site over HTTP for previewing it
+// locally. Every HTML page gets a small script that reloads the page
+// when the site is rebuilt.
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ReloadPath is the URL the reload script listens to for server sent
+// events.
+const ReloadPath = "/_goblog/reload"
+
+// reloadScript is injected into every HTML page.
+var reloadScript = []byte(`<script>
+(function() {
+  var source = new EventSource("` + ReloadPath + `");
+  source.onmessage = function() { window.location.reload(); };
+})();
+</script>
+`)
+
+// Server is an http.Handler that serves the files in a directory and
+// tells the open browsers to reload when Reload is called.
+// 用来在本地预览生成的站点，站点重新生成之后会通知浏览器刷新页面
+type Server struct {
+	// dir is the directory being served.
+	dir string
+
+	// files serves everything that isn't an HTML page.
+	files http.Handler
+
+	// clients are the browsers waiting for a reload.
+	clients map[chan bool]bool
+	mu      sync.Mutex
+}
+
+// New returns a Server for the given directory.
+func New(dir string) *Server {
+	return &Server{
+		dir:     dir,
+		files:   http.FileServer(http.Dir(dir)),
+		clients: map[chan bool]bool{},
+	}
+}
+
+// Reload tells every open browser to reload the page.
+func (s *Server) Reload() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.clients {
+		// Don't block on a browser that already has a reload pending.
+		select {
+		case c <- true:
+		default:
+		}
+	}
+}
+
+// ServeHTTP serves the reload events, HTML pages with the reload script
+// injected and every other file as is.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == ReloadPath {
+		s.serveEvents(w, r)
+		return
+	}
+
+	// Find the file, using index.html for directories.
+	p := path.Clean("/" + r.URL.Path)
+	file := filepath.Join(s.dir, filepath.FromSlash(p))
+	fi, err := os.Stat(file)
+	if err == nil && fi.IsDir() {
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+		file = filepath.Join(file, "index.html")
+	}
+
+	if strings.HasSuffix(file, ".html") {
+		contents, err := ioutil.ReadFile(file)
+		if err == nil {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Cache-Control", "no-store")
+			w.Write(InjectScript(contents))
+			return
+		}
+	}
+
+	s.files.ServeHTTP(w, r)
+}
+
+// serveEvents keeps the connection open and sends an event every time
+// the site is reloaded.
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher.Flush()
+
+	c := make(chan bool, 1)
+	s.mu.Lock()
+	s.clients[c] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, c)
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-c:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// InjectScript puts the reload script right before the closing body
+// tag of the given page, or at the end if there isn't one.
+func InjectScript(page []byte) []byte {
+	i := bytes.LastIndex(bytes.ToLower(page), []byte("</body>"))
+	if i < 0 {
+		return append(page, reloadScript...)
+	}
+
+	result := make([]byte, 0, len(page)+len(reloadScript))
+	result = append(result, page[:i]...)
+	result = append(result, reloadScript...)
+	return append(result, page[i:]...)
+}
@@ -0,0 +1,73 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package server
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestServeHTTP makes sure HTML pages get the reload script and other
+// files don't.
+func TestServeHTTP(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"index.html":          "<html><body>home</body></html>",
+		"style.css":           "body{}",
+		"projects/index.html": "<p>projects</p>",
+	}
+	for name, contents := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0750)
+		if err := ioutil.WriteFile(p, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		url    string
+		code   int
+		script bool
+		body   string
+	}{
+		{url: "/", code: 200, script: true, body: "home<script>"},
+		{url: "/projects/", code: 200, script: true, body: "projects"},
+		{url: "/projects", code: 301},
+		{url: "/style.css", code: 200, script: false, body: "body{}"},
+		{url: "/missing.html", code: 404},
+	}
+
+	s := New(dir)
+	for i, test := range tests {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest("GET", test.url, nil))
+
+		if w.Code != test.code {
+			t.Errorf("(%d) expecting code %d for %s but got %d", i,
+				test.code, test.url, w.Code)
+			continue
+		}
+
+		body := w.Body.String()
+		if strings.Contains(body, ReloadPath) != test.script {
+			t.Errorf("(%d) expecting script %v for %s: %s", i,
+				test.script, test.url, body)
+		}
+
+		if !strings.Contains(body, test.body) {
+			t.Errorf("(%d) expecting %q in %s: %s", i, test.body,
+				test.url, body)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	flag "github.com/ogier/pflag"
+	"github.com/pyanfield/goblog/config"
+	"os"
+)
+
+// Site is the site configuration after merging the configuration file,
+// the environment and the flags. It is passed to the templates.
+// 合并了配置文件，环境变量和命令行参数之后的站点配置
+var Site = &config.Config{}
+
+// ConfigFile is the configuration file Site was loaded from, or "" if
+// there isn't one.
+var ConfigFile string
+
+// configFlags are the flags that are merged with the configuration.
+var configFlags = []string{"url", "output-dir", "template-dir", "blog-dir",
+	"page-dir", "static-dir", "index-entries", "raw-templates"}
+
+// loadConfig reads the configuration file from the WorkingDir and
+// merges it with the environment and the flags. A flag that was given
+// on the command line wins over the environment, which wins over the
+// configuration file, which wins over the flag defaults. Afterwards,
+// the flag variables and Site agree with each other. It can be called
+// again when the configuration file changes.
+// 读取配置文件并与环境变量和命令行参数合并，命令行参数的优先级最高
+func loadConfig() error {
+	c, file, err := config.Load(WorkingDir)
+	if err != nil {
+		return err
+	}
+
+	err = c.ApplyEnv(os.Getenv)
+	if err != nil {
+		return err
+	}
+
+	// Find the flags that were given on the command line.
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	// The ones that weren't start over from their defaults, so nothing
+	// is left from a configuration loaded before.
+	for _, name := range configFlags {
+		if f := flag.Lookup(name); !set[name] {
+			f.Value.Set(f.DefValue)
+		}
+	}
+
+	mergeString(set["url"], &URL, &c.URL)
+	mergeString(set["output-dir"], &OutputDir, &c.OutputDir)
+	mergeString(set["template-dir"], &TemplateDir, &c.TemplateDir)
+	mergeString(set["blog-dir"], &BlogDir, &c.BlogDir)
+	mergeString(set["page-dir"], &PageDir, &c.PageDir)
+	mergeString(set["static-dir"], &StaticDir, &c.StaticDir)
+	mergeInt(set["index-entries"], &MaxIndexEntries, &c.IndexEntries)
+	mergeBool(set["raw-templates"], &RawTemplates, &c.RawTemplates)
+
+	Site = c
+	ConfigFile = file
+	return nil
+}
+
+// mergeString sets both the flag and the configuration value to the
+// one that wins.
+func mergeString(flagSet bool, flagValue, configValue *string) {
+	if flagSet || *configValue == "" {
+		*configValue = *flagValue
+	} else {
+		*flagValue = *configValue
+	}
+}
+
+// mergeInt sets both the flag and the configuration value to the one
+// that wins. A zero configuration value means it wasn't given.
+func mergeInt(flagSet bool, flagValue, configValue *int) {
+	if flagSet || *configValue == 0 {
+		*configValue = *flagValue
+	} else {
+		*flagValue = *configValue
+	}
+}
+
+// mergeBool sets both the flag and the configuration value to the one
+// that wins. A false configuration value means it wasn't given.
+func mergeBool(flagSet bool, flagValue, configValue *bool) {
+	if flagSet || !*configValue {
+		*configValue = *flagValue
+	} else {
+		*flagValue = *configValue
+	}
+}
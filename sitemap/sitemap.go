@@ -0,0 +1,220 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+// Package sitemap tells search engines about the pages of the site. It
+// writes the sitemap.xml of the generated pages (split up behind a
+// sitemap index when there are too many of them for one file) and a
+// robots.txt that points at it.
+package sitemap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/pyanfield/goblog/blogs"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// File is the sitemap, or the sitemap index when there is more than
+// one part.
+const File = "sitemap.xml"
+
+// xmlns is the namespace of sitemaps and sitemap indexes.
+const xmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+var (
+	// maxURLs is the most urls the protocol allows in one sitemap.
+	maxURLs = 50000
+
+	// maxSize is the most bytes the protocol allows in one sitemap.
+	maxSize = 50 * 1024 * 1024
+)
+
+// URL is a page in the sitemap.
+type URL struct {
+	// Loc is the url of the page from the root of the site (e.g.
+	// 2013/04/intro/). The sitemap has it in full.
+	Loc string
+
+	// LastMod is when the page last changed. It's left out when it's
+	// the zero time.
+	LastMod time.Time
+}
+
+// New returns the URL of the page with the given url from the root of
+// the site. It was last changed when the most recently updated of the
+// given entries, the ones on the page, was.
+func New(page string, entries ...*blogs.BlogEntry) URL {
+	u := URL{Loc: strings.TrimPrefix(page, "/")}
+	for _, entry := range entries {
+		if entry.Updated.After(u.LastMod) {
+			u.LastMod = entry.Updated
+		}
+	}
+
+	return u
+}
+
+// urlElement is the <url> of a URL.
+type urlElement struct {
+	XMLName xml.Name `xml:"url"`
+	Loc     string   `xml:"loc"`
+	LastMod string   `xml:"lastmod,omitempty"`
+}
+
+// sitemapElement is the <sitemap> of a part in a sitemap index.
+type sitemapElement struct {
+	XMLName xml.Name `xml:"sitemap"`
+	Loc     string   `xml:"loc"`
+	LastMod string   `xml:"lastmod,omitempty"`
+}
+
+// lastMod returns the <lastmod> of t, or "" for the zero time.
+func lastMod(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+// marshal returns the <url> of u on the site at base.
+func (u URL) marshal(base string) ([]byte, error) {
+	return xml.MarshalIndent(urlElement{
+		Loc:     base + u.Loc,
+		LastMod: lastMod(u.LastMod),
+	}, "  ", "  ")
+}
+
+// Split splits the urls of the site at siteUrl into the parts of the
+// sitemap. Each part is within the limits of the protocol, 50,000 urls
+// and 50MB. There is always at least one part.
+func Split(siteUrl string, urls []URL) ([][]URL, error) {
+	b, err := base(siteUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := [][]URL{nil}
+	size := len(header) + len(footer)
+	for _, u := range urls {
+		out, err := u.marshal(b)
+		if err != nil {
+			return nil, err
+		}
+
+		part := parts[len(parts)-1]
+		full := len(part) == maxURLs || size+len(out)+1 > maxSize
+		if len(part) > 0 && full {
+			parts = append(parts, nil)
+			size = len(header) + len(footer)
+		}
+
+		parts[len(parts)-1] = append(parts[len(parts)-1], u)
+		size += len(out) + 1
+	}
+
+	return parts, nil
+}
+
+// PartFile returns the file of the given part (from 0) of a sitemap
+// that is split up.
+func PartFile(i int) string {
+	return fmt.Sprintf("sitemap-%d.xml", i+1)
+}
+
+// header and footer are around the urls of a sitemap.
+const (
+	header = xml.Header + `<urlset xmlns="` + xmlns + `">` + "\n"
+	footer = "</urlset>\n"
+)
+
+// Make writes the sitemap of the given urls of the site at siteUrl to
+// the given file in the given directory.
+// 生成 sitemap.xml
+func Make(dir, file, siteUrl string, urls []URL) error {
+	b, err := base(siteUrl)
+	if err != nil {
+		return err
+	}
+
+	sw := bytes.NewBufferString(header)
+	for _, u := range urls {
+		out, err := u.marshal(b)
+		if err != nil {
+			return err
+		}
+		sw.Write(out)
+		sw.WriteString("\n")
+	}
+	sw.WriteString(footer)
+
+	return ioutil.WriteFile(path.Join(dir, file), sw.Bytes(), 0644)
+}
+
+// MakeIndex writes the sitemap index of the given parts, which are
+// written with Make to their PartFile, to File in the given directory.
+// Each part was last changed when its most recent url was.
+// 生成 sitemap 索引
+func MakeIndex(dir, siteUrl string, parts [][]URL) error {
+	b, err := base(siteUrl)
+	if err != nil {
+		return err
+	}
+
+	sw := bytes.NewBufferString(xml.Header)
+	sw.WriteString(`<sitemapindex xmlns="` + xmlns + `">` + "\n")
+	for i, part := range parts {
+		var t time.Time
+		for _, u := range part {
+			if u.LastMod.After(t) {
+				t = u.LastMod
+			}
+		}
+
+		out, err := xml.MarshalIndent(sitemapElement{
+			Loc:     b + PartFile(i),
+			LastMod: lastMod(t),
+		}, "  ", "  ")
+		if err != nil {
+			return err
+		}
+		sw.Write(out)
+		sw.WriteString("\n")
+	}
+	sw.WriteString("</sitemapindex>\n")
+
+	return ioutil.WriteFile(path.Join(dir, File), sw.Bytes(), 0644)
+}
+
+// MakeRobots writes a robots.txt that lets every crawler in and points
+// them at the sitemap into the given directory.
+// 生成 robots.txt
+func MakeRobots(dir, siteUrl string) error {
+	b, err := base(siteUrl)
+	if err != nil {
+		return err
+	}
+
+	robots := fmt.Sprintf("User-agent: *\nAllow: /\n\nSitemap: %s%s\n",
+		b, File)
+
+	return ioutil.WriteFile(path.Join(dir, "robots.txt"), []byte(robots), 0644)
+}
+
+// base returns the site url the urls of the pages are added to. Both
+// sitemaps and robots.txt need full urls, so it's an error when the
+// site doesn't have one.
+func base(siteUrl string) (string, error) {
+	u, err := url.Parse(siteUrl)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return "", fmt.Errorf("the site url %q isn't a full url", siteUrl)
+	}
+
+	return strings.TrimSuffix(siteUrl, "/") + "/", nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package sitemap
+
+import (
+	"github.com/pyanfield/goblog/blogs"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+// TestNew tests the urls of the pages and when they last changed.
+func TestNew(t *testing.T) {
+	old := &blogs.BlogEntry{Updated: time.Date(2013, 4, 9, 0, 0, 0, 0, time.UTC)}
+	last := &blogs.BlogEntry{Updated: time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		url     string
+		entries []*blogs.BlogEntry
+		loc     string
+		lastMod time.Time
+	}{
+		{"2013/04/intro/", []*blogs.BlogEntry{old}, "2013/04/intro/",
+			old.Updated},
+		{"/", []*blogs.BlogEntry{old, last}, "", last.Updated},
+		{"/archives/2/", nil, "archives/2/", time.Time{}},
+	}
+
+	for i, test := range tests {
+		u := New(test.url, test.entries...)
+		if u.Loc != test.loc || !u.LastMod.Equal(test.lastMod) {
+			t.Errorf("(%d) expecting %s %v but got %+v", i, test.loc,
+				test.lastMod, u)
+		}
+	}
+}
+
+// TestBase tests the site urls the sitemap accepts. The protocol
+// needs full urls, so a site without one doesn't get a sitemap.
+func TestBase(t *testing.T) {
+	tests := []struct {
+		site     string
+		expected string
+		err      bool
+	}{
+		{"http://example.com", "http://example.com/", false},
+		{"https://example.com/blog/", "https://example.com/blog/", false},
+		{"", "", true},
+		{"/blog/", "", true},
+		{"example.com", "", true},
+	}
+
+	for i, test := range tests {
+		b, err := base(test.site)
+		if (err != nil) != test.err || b != test.expected {
+			t.Errorf("(%d) expecting %q (error %v) but got %q (%v)", i,
+				test.expected, test.err, b, err)
+		}
+	}
+}
+
+// TestEmptyURL makes sure nothing is written for a site without a url.
+func TestEmptyURL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sitemap")
+	if err != nil {
+		t.Fatalf("making temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	urls := []URL{New("about.html")}
+	if _, err := Split("", urls); err == nil {
+		t.Errorf("expecting Split to fail")
+	}
+	if err := Make(dir, File, "", urls); err == nil {
+		t.Errorf("expecting Make to fail")
+	}
+	if err := MakeIndex(dir, "", [][]URL{urls}); err == nil {
+		t.Errorf("expecting MakeIndex to fail")
+	}
+	if err := MakeRobots(dir, ""); err == nil {
+		t.Errorf("expecting MakeRobots to fail")
+	}
+
+	for _, file := range []string{File, "robots.txt"} {
+		if _, err := os.Stat(path.Join(dir, file)); err == nil {
+			t.Errorf("expecting no %s", file)
+		}
+	}
+}
+
+// TestSplit makes sure the sitemap is split up at the limits of the
+// protocol.
+func TestSplit(t *testing.T) {
+	defer func(urls, size int) {
+		maxURLs, maxSize = urls, size
+	}(maxURLs, maxSize)
+
+	urls := []URL{}
+	for i := 0; i < 5; i++ {
+		urls = append(urls, New("page.html"))
+	}
+	out, _ := urls[0].marshal("http://example.com/")
+
+	tests := []struct {
+		urls, size int
+		expected   []int
+	}{
+		{50000, 50 * 1024 * 1024, []int{5}},
+		{2, 50 * 1024 * 1024, []int{2, 2, 1}},
+		{5, len(header) + len(footer) + 3*(len(out)+1), []int{3, 2}},
+		{5, 1, []int{1, 1, 1, 1, 1}},
+	}
+
+	for i, test := range tests {
+		maxURLs, maxSize = test.urls, test.size
+
+		parts, err := Split("http://example.com/", urls)
+		if err != nil {
+			t.Fatalf("(%d) splitting: %s", i, err)
+		}
+
+		sizes := []int{}
+		for _, part := range parts {
+			sizes = append(sizes, len(part))
+		}
+		if len(sizes) != len(test.expected) {
+			t.Errorf("(%d) expecting parts %v but got %v", i, test.expected, sizes)
+			continue
+		}
+		for j := range sizes {
+			if sizes[j] != test.expected[j] {
+				t.Errorf("(%d) expecting parts %v but got %v", i, test.expected,
+					sizes)
+				break
+			}
+		}
+	}
+
+	parts, _ := Split("http://example.com/", nil)
+	if len(parts) != 1 || len(parts[0]) != 0 {
+		t.Errorf("expecting one empty part but got %v", parts)
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+// Package skeleton contains the files of a new goblog workspace: a
+// configuration file and a first blog entry. The templates and the
+// stylesheet come from the default theme (see the themes package).
+package skeleton
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed files
+var files embed.FS
+
+// Files returns the skeleton files: goblog.toml and the blogs
+// directory.
+func Files() fs.FS {
+	sub, err := fs.Sub(files, "files")
+	if err != nil {
+		// It's compiled in, so this can't happen.
+		panic(err)
+	}
+
+	return sub
+}
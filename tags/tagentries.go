@@ -9,9 +9,9 @@ import (
 	"sort"
 )
 
-// TagEntries is a map of TagEntry structures with some methods for
-// easily adding blog entries. It also has the ability to export the
-// entries as a list for further processing.
+// TagEntries is a map of TagEntry structures, by Slug, with some
+// methods for easily adding blog entries. It also has the ability to
+// export the entries as a list for further processing.
 type TagEntries map[string]*TagEntry
 
 // ParseBlogs builds a TagEntries from the given list of blogs.
@@ -26,7 +26,8 @@ func ParseBlogs(entries []*blogs.BlogEntry) TagEntries {
 }
 
 // Slice returns the TagEntry structures with this TagEntries as a
-// slice. The list is in sorted order.
+// slice. The list is in sorted order and the entries of each tag are
+// newest first.
 func (te TagEntries) Slice() TagEntriesSlice {
 	s := make(TagEntriesSlice, 0, len(te))
 
@@ -36,23 +37,32 @@ func (te TagEntries) Slice() TagEntriesSlice {
 
 	// Sort the slice.
 	sort.Sort(s)
+	for _, t := range s {
+		sort.SliceStable(t.Entries, func(i, j int) bool {
+			return t.Entries[j].Created.Before(t.Entries[i].Created)
+		})
+	}
 
 	return s
 }
 
-// Add links the given BlogEntry to all of it's tags.
+// Add links the given BlogEntry to all of it's tags. Tags with the same
+// Slug (e.g. "Go" and "go") are the same tag; the first name seen is
+// used.
 func (te TagEntries) Add(e *blogs.BlogEntry) {
 	for _, tag := range e.Tags {
-		f, ok := te[tag]
+		t := NewTagEntry(tag)
+		f, ok := te[t.Slug]
 		if !ok {
-			// It wasn't found, so create one.
-			te[tag] = &TagEntry{
-				Name:    tag,
-				Entries: []*blogs.BlogEntry{e},
-			}
-		} else {
-			// Add it to the one we found.
-			f.Add(e)
+			// It wasn't found, so use the new one.
+			te[t.Slug] = t
+			f = t
+		}
+
+		// Don't add an entry twice for tags like "Go" and "go".
+		if n := len(f.Entries); n > 0 && f.Entries[n-1] == e {
+			continue
 		}
+		f.Add(e)
 	}
 }
@@ -0,0 +1,57 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package tags
+
+import (
+	"github.com/pyanfield/goblog/blogs"
+	"testing"
+	"time"
+)
+
+// TestParseBlogs tests that tags are grouped by their slugs and that
+// the entries of each tag are newest first.
+func TestParseBlogs(t *testing.T) {
+	old := &blogs.BlogEntry{Name: "old", Created: time.Unix(0, 0),
+		Tags: []string{"Go", "go", "C++", "#"}}
+	new := &blogs.BlogEntry{Name: "new", Created: time.Unix(120, 0),
+		Tags: []string{"go", "Web Design"}}
+
+	tests := []struct {
+		name, slug, url string
+		entries         []*blogs.BlogEntry
+	}{
+		{"#", "tag-23", "tags/tag-23/", []*blogs.BlogEntry{old}},
+		{"C++", "c", "tags/c/", []*blogs.BlogEntry{old}},
+		{"Go", "go", "tags/go/", []*blogs.BlogEntry{new, old}},
+		{"Web Design", "web-design", "tags/web-design/",
+			[]*blogs.BlogEntry{new}},
+	}
+
+	result := ParseBlogs([]*blogs.BlogEntry{old, new}).Slice()
+	if len(result) != len(tests) {
+		t.Fatalf("expecting %d tags but got %d", len(tests), len(result))
+	}
+
+	for i, test := range tests {
+		te := result[i]
+		if te.Name != test.name || te.Slug != test.slug || te.Url != test.url {
+			t.Errorf("(%d) expecting %s, %s, %s but got %s, %s, %s", i,
+				test.name, test.slug, test.url, te.Name, te.Slug, te.Url)
+		}
+
+		if len(te.Entries) != len(test.entries) {
+			t.Errorf("(%d) expecting %d entries but got %d", i,
+				len(test.entries), len(te.Entries))
+			continue
+		}
+
+		for k, e := range test.entries {
+			if te.Entries[k] != e {
+				t.Errorf("(%d) expecting '%s' at %d but got '%s'", i,
+					e.Name, k, te.Entries[k].Name)
+			}
+		}
+	}
+}
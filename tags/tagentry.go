@@ -5,6 +5,7 @@
 package tags
 
 import (
+	"encoding/hex"
 	"github.com/pyanfield/goblog/blogs"
 )
 
@@ -14,6 +15,14 @@ type TagEntry struct {
 	// The name of the Tag.
 	Name string
 
+	// Slug is the Name made usable in a url (e.g. "Go Tips" is
+	// "go-tips").
+	Slug string
+
+	// Url is the url of the tag's page from the root of the site (e.g.
+	// "tags/go-tips/"). The tag's feed is Url + "feed.rss".
+	Url string
+
 	// The list of Entries associated with this tag.
 	Entries []*blogs.BlogEntry
 }
@@ -27,3 +36,19 @@ func (te *TagEntry) Add(e *blogs.BlogEntry) {
 
 	te.Entries = append(te.Entries, e)
 }
+
+// NewTagEntry returns an empty TagEntry for the tag with the given name.
+func NewTagEntry(name string) *TagEntry {
+	slug := blogs.Slugify(name)
+	if slug == "" {
+		// There's nothing left of names like "#", so use the bytes of
+		// the name instead. They are safe in a url and as a file name.
+		slug = "tag-" + hex.EncodeToString([]byte(name))
+	}
+
+	return &TagEntry{
+		Name: name,
+		Slug: slug,
+		Url:  "tags/" + slug + "/",
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package templates
+
+import (
+	"html/template"
+	"io"
+	textTemplate "text/template"
+)
+
+// Executor is a parsed template. Both *html/template.Template and
+// *text/template.Template are Executors.
+type Executor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// set is a group of templates that can use each other. Both
+// *html/template.Template and *text/template.Template are sets.
+type set interface {
+	ExecuteTemplate(w io.Writer, name string, data interface{}) error
+}
+
+// parseSets parses the shared templates (site.html and the partials)
+// and then makes a set for each of the pages. Each page's set is a copy
+// of the shared templates with the page added, so a page can use the
+// partials and its {{define}}s replace the {{block}}s of site.html when
+// the page is rendered. The shared templates on their own are the set
+// named "site".
+//
+// Unless raw is set, html/template is used so every value is escaped
+// for the context it appears in. With raw set, text/template is used
+// and the values are output as they are, which is how goblog worked
+// before and what some older themes depend on.
+// 解析共享的模版（site.html 和 partials），然后为每个页面生成一个包含共享模版的模版集合。
+// 默认使用 html/template 根据上下文转义变量，raw 为 true 时使用 text/template 原样输出
+func parseSets(shared, pages map[string]string, funcs map[string]interface{},
+	raw bool) (map[string]set, error) {
+
+	if raw {
+		return parseTextSets(shared, pages, funcs)
+	}
+
+	return parseHTMLSets(shared, pages, funcs)
+}
+
+// parseHTMLSets is parseSets for html/template.
+func parseHTMLSets(shared, pages map[string]string,
+	funcs map[string]interface{}) (map[string]set, error) {
+
+	base := template.New("").Funcs(template.FuncMap(funcs))
+	for name, contents := range shared {
+		_, err := base.New(name).Parse(contents)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sets := map[string]set{}
+	for name, contents := range pages {
+		s, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = s.New(name).Parse(contents)
+		if err != nil {
+			return nil, err
+		}
+		sets[name] = s
+	}
+
+	sets["site"] = base
+
+	return sets, nil
+}
+
+// parseTextSets is parseSets for text/template.
+func parseTextSets(shared, pages map[string]string,
+	funcs map[string]interface{}) (map[string]set, error) {
+
+	base := textTemplate.New("").Funcs(textTemplate.FuncMap(funcs))
+	for name, contents := range shared {
+		_, err := base.New(name).Parse(contents)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sets := map[string]set{}
+	for name, contents := range pages {
+		s, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = s.New(name).Parse(contents)
+		if err != nil {
+			return nil, err
+		}
+		sets[name] = s
+	}
+
+	sets["site"] = base
+
+	return sets, nil
+}
@@ -0,0 +1,341 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package templates
+
+import (
+	"fmt"
+	"github.com/pyanfield/goblog/blogs"
+	"github.com/pyanfield/goblog/config"
+	"github.com/pyanfield/goblog/tags"
+	md "github.com/russross/blackfriday"
+	"html/template"
+	"net/url"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Funcs returns the functions available to every template. The url
+// functions use the url of the given site configuration.
+//
+//      dateFormat LAYOUT TIME - Formats a time.Time (e.g. .Created)
+//                               with a Go layout like "Jan 2, 2006".
+//      truncate N TEXT        - Shortens TEXT to N characters, adding
+//                               "…" when something was cut. HTML (like
+//                               .Content) has its tags removed first.
+//      slugify TEXT           - Makes TEXT usable in a url.
+//      tagURL TAG             - The url of the page of the tag TAG
+//                               (e.g. tags/go/), for relURL.
+//      markdownify TEXT       - Renders markdown to HTML.
+//      urlJoin PARTS...       - Joins the parts of a url with slashes.
+//      relURL PATH            - The url of PATH from the root of the
+//                               site (e.g. /blog/tags.html).
+//      absURL PATH            - The full url of PATH including the
+//                               site url.
+//      where LIST KEY VALUE   - The items of LIST whose KEY (a field,
+//                               method or map key, like "Params.series")
+//                               equals or, for lists, contains VALUE.
+//      sortBy LIST KEY [desc] - LIST sorted by KEY.
+//      first N LIST           - The first N items of LIST.
+//      safeHTML, safeURL, safeJS TEXT - Mark TEXT as trusted so it
+//                               isn't escaped.
+// 所有模版都可以使用的函数
+func Funcs(site *config.Config) map[string]interface{} {
+	return map[string]interface{}{
+		"dateFormat":  dateFormat,
+		"truncate":    truncate,
+		"slugify":     blogs.Slugify,
+		"tagURL":      func(tag string) string { return tags.NewTagEntry(tag).Url },
+		"markdownify": markdownify,
+		"urlJoin":     urlJoin,
+		"relURL": func(p string) string {
+			return relURL(site, p)
+		},
+		"absURL": func(p string) string {
+			return absURL(site, p)
+		},
+		"where":    where,
+		"sortBy":   sortBy,
+		"first":    first,
+		"safeHTML": func(s string) template.HTML { return template.HTML(s) },
+		"safeURL":  func(s string) template.URL { return template.URL(s) },
+		"safeJS":   func(s string) template.JS { return template.JS(s) },
+	}
+}
+
+// dateFormat formats t with the given layout. The zero time is "".
+func dateFormat(layout string, t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(layout)
+}
+
+// truncate returns the first n characters of s. s may be a string or
+// HTML, in which case the tags are removed.
+func truncate(n int, s interface{}) (string, error) {
+	var text string
+	switch v := s.(type) {
+	case string:
+		text = v
+	case template.HTML:
+		text = blogs.PlainText(string(v))
+	default:
+		return "", fmt.Errorf("truncate: can't truncate %T", s)
+	}
+
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text, nil
+	}
+
+	return strings.TrimSpace(string(runes[:n])) + "…", nil
+}
+
+// markdownify renders the given markdown.
+func markdownify(s string) template.HTML {
+	return template.HTML(md.MarkdownCommon([]byte(s)))
+}
+
+// urlJoin joins the parts with exactly one slash between each of
+// them. The slashes at the very start and end are kept.
+func urlJoin(parts ...string) string {
+	trimmed := []string{}
+	for i, p := range parts {
+		if i > 0 {
+			p = strings.TrimLeft(p, "/")
+		}
+		if i < len(parts)-1 {
+			p = strings.TrimRight(p, "/")
+		}
+		if p != "" {
+			trimmed = append(trimmed, p)
+		}
+	}
+
+	return strings.Join(trimmed, "/")
+}
+
+// isAbs returns true if p is a full url (e.g. http://example.com/).
+func isAbs(p string) bool {
+	u, err := url.Parse(p)
+	return err == nil && u.Scheme != ""
+}
+
+// relURL returns p from the root of the site. If the site url has a
+// path (e.g. http://example.com/blog/), it's put in front of p.
+func relURL(site *config.Config, p string) string {
+	if isAbs(p) {
+		return p
+	}
+
+	base := "/"
+	if site != nil {
+		if u, err := url.Parse(site.URL); err == nil && u.Path != "" {
+			base = u.Path
+		}
+	}
+
+	rel := path.Join(base, p)
+	if strings.HasSuffix(p, "/") && rel != "/" {
+		rel += "/"
+	}
+	return rel
+}
+
+// absURL returns the full url of p.
+func absURL(site *config.Config, p string) string {
+	if isAbs(p) || site == nil || site.URL == "" {
+		return relURL(site, p)
+	}
+
+	u, err := url.Parse(site.URL)
+	if err != nil {
+		return relURL(site, p)
+	}
+
+	return u.Scheme + "://" + u.Host + relURL(site, p)
+}
+
+// lookup returns the value of key in v. The key may be a field, a
+// method without arguments or a map key. Dots go further in (e.g.
+// "Params.series").
+func lookup(v reflect.Value, key string) (reflect.Value, error) {
+	for _, name := range strings.Split(key, ".") {
+		if v.Kind() == reflect.Interface && !v.IsNil() {
+			v = v.Elem()
+		}
+
+		// Methods may be on the pointer, so look before going in.
+		if m := v.MethodByName(name); m.IsValid() {
+			if m.Type().NumIn() != 0 || m.Type().NumOut() == 0 {
+				return reflect.Value{}, fmt.Errorf("%s can't be called", name)
+			}
+			v = m.Call(nil)[0]
+			continue
+		}
+
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return reflect.Value{}, nil
+			}
+			v = v.Elem()
+		}
+
+		switch v.Kind() {
+		case reflect.Struct:
+			v = v.FieldByName(name)
+			if !v.IsValid() {
+				return reflect.Value{}, fmt.Errorf("no field %s", name)
+			}
+		case reflect.Map:
+			v = v.MapIndex(reflect.ValueOf(name))
+			if !v.IsValid() {
+				return reflect.Value{}, nil
+			}
+		default:
+			return reflect.Value{}, fmt.Errorf("can't get %s from %s", name, v.Type())
+		}
+	}
+
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v, nil
+}
+
+// list returns l as a slice value.
+func list(name string, l interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(l)
+	if v.Kind() != reflect.Slice {
+		return reflect.Value{}, fmt.Errorf("%s: %T isn't a list", name, l)
+	}
+
+	return v, nil
+}
+
+// matches returns true if v is value or, when v is a list, contains
+// it.
+func matches(v reflect.Value, value interface{}) bool {
+	if !v.IsValid() {
+		return value == nil
+	}
+
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		for i := 0; i < v.Len(); i++ {
+			if matches(v.Index(i), value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return fmt.Sprint(v.Interface()) == fmt.Sprint(value)
+}
+
+// where returns the items of l whose key matches value.
+func where(l interface{}, key string, value interface{}) (interface{}, error) {
+	v, err := list("where", l)
+	if err != nil {
+		return nil, err
+	}
+
+	result := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		field, err := lookup(v.Index(i), key)
+		if err != nil {
+			return nil, fmt.Errorf("where: %s", err)
+		}
+
+		if matches(field, value) {
+			result = reflect.Append(result, v.Index(i))
+		}
+	}
+
+	return result.Interface(), nil
+}
+
+// less compares two values of the same kind.
+func less(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return !a.IsValid() && b.IsValid()
+	}
+
+	if t, ok := a.Interface().(time.Time); ok {
+		if u, ok := b.Interface().(time.Time); ok {
+			return t.Before(u)
+		}
+	}
+
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	}
+
+	return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+}
+
+// sortBy returns a sorted copy of l. The items are compared by key and
+// the order is "asc" (the default) or "desc".
+func sortBy(l interface{}, key string, order ...string) (interface{}, error) {
+	v, err := list("sortBy", l)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := len(order) > 0 && strings.ToLower(order[0]) == "desc"
+
+	items := make([]reflect.Value, v.Len())
+	keys := make([]reflect.Value, v.Len())
+	for i := range items {
+		items[i] = v.Index(i)
+		keys[i], err = lookup(items[i], key)
+		if err != nil {
+			return nil, fmt.Errorf("sortBy: %s", err)
+		}
+	}
+
+	idx := make([]int, len(items))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		if desc {
+			return less(keys[idx[j]], keys[idx[i]])
+		}
+		return less(keys[idx[i]], keys[idx[j]])
+	})
+
+	result := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), 0, len(items))
+	for _, i := range idx {
+		result = reflect.Append(result, items[i])
+	}
+
+	return result.Interface(), nil
+}
+
+// first returns the first n items of l.
+func first(n int, l interface{}) (interface{}, error) {
+	v, err := list("first", l)
+	if err != nil {
+		return nil, err
+	}
+
+	if n < 0 {
+		return nil, fmt.Errorf("first: %d is negative", n)
+	}
+	if n > v.Len() {
+		n = v.Len()
+	}
+
+	return v.Slice(0, n).Interface(), nil
+}
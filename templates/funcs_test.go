@@ -0,0 +1,78 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package templates
+
+import (
+	"bytes"
+	"github.com/pyanfield/goblog/blogs"
+	"github.com/pyanfield/goblog/config"
+	"html/template"
+	"testing"
+	"time"
+)
+
+// TestFuncs tests the template functions by executing them in small
+// templates.
+func TestFuncs(t *testing.T) {
+	site := &config.Config{URL: "http://example.com/blog/"}
+	entries := []*blogs.BlogEntry{
+		{Title: "B", Tags: []string{"go"},
+			Created: time.Date(2013, time.April, 5, 0, 0, 0, 0, time.UTC),
+			Params:  map[string]interface{}{"series": "x"}},
+		{Title: "A", Tags: []string{"rss", "go"},
+			Created: time.Date(2013, time.May, 1, 0, 0, 0, 0, time.UTC)},
+		{Title: "C",
+			Created: time.Date(2012, time.June, 9, 0, 0, 0, 0, time.UTC),
+			Params:  map[string]interface{}{"series": "x"}},
+	}
+
+	tests := []struct {
+		tmplt    string
+		expected string
+	}{
+		{`{{dateFormat "Jan 2, 2006" (index . 0).Created}}`, "Apr 5, 2013"},
+		{`{{truncate 5 "Hello, World"}}`, "Hello…"},
+		{`{{truncate 20 "short"}}`, "short"},
+		{`{{truncate 8 (safeHTML "<p>Hello <em>big</em> World</p>")}}`, "Hello bi…"},
+		{`{{truncate 20 (safeHTML "<p>Fish &amp; <b>chips</b></p>")}}`, "Fish &amp; chips"},
+		{`{{slugify "Hello, World!"}}`, "hello-world"},
+		{`{{tagURL "Go Tips"}}`, "tags/go-tips/"},
+		{`{{markdownify "*hi*"}}`, "<p><em>hi</em></p>\n"},
+		{`{{urlJoin "http://example.com/" "/tags/" "go.html"}}`, "http://example.com/tags/go.html"},
+		{`{{relURL "tags.html"}}`, "/blog/tags.html"},
+		{`{{relURL "/"}}`, "/blog/"},
+		{`{{absURL "tags.html"}}`, "http://example.com/blog/tags.html"},
+		{`{{absURL "https://other.com/"}}`, "https://other.com/"},
+		{`{{range where . "Tags" "rss"}}{{.Title}}{{end}}`, "A"},
+		{`{{range where . "Params.series" "x"}}{{.Title}}{{end}}`, "BC"},
+		{`{{range sortBy . "Title"}}{{.Title}}{{end}}`, "ABC"},
+		{`{{range sortBy . "Created" "desc"}}{{.Title}}{{end}}`, "ABC"},
+		{`{{range sortBy . "CDate"}}{{.Title}}{{end}}`, "CBA"},
+		{`{{range first 2 .}}{{.Title}}{{end}}`, "BA"},
+		{`{{range first 5 .}}{{.Title}}{{end}}`, "BAC"},
+		{`<a href="{{safeURL "javascript:go"}}">`, `<a href="javascript:go">`},
+		{`<script>var x = {{safeJS "1 + 1"}};</script>`, `<script>var x = 1 + 1;</script>`},
+	}
+
+	for i, test := range tests {
+		tmplt, err := template.New("test").Funcs(Funcs(site)).Parse(test.tmplt)
+		if err != nil {
+			t.Errorf("(%d) parsing: %s", i, err)
+			continue
+		}
+
+		buf := new(bytes.Buffer)
+		err = tmplt.Execute(buf, entries)
+		if err != nil {
+			t.Errorf("(%d) executing: %s", i, err)
+			continue
+		}
+
+		if buf.String() != test.expected {
+			t.Errorf("(%d) expecting '%s' but got '%s'", i, test.expected,
+				buf.String())
+		}
+	}
+}
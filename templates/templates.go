@@ -6,19 +6,48 @@ package templates
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"github.com/pyanfield/goblog/archives"
 	"github.com/pyanfield/goblog/blogs"
+	"github.com/pyanfield/goblog/config"
+	"github.com/pyanfield/goblog/pages"
+	"github.com/pyanfield/goblog/paginator"
+	"github.com/pyanfield/goblog/rss"
 	"github.com/pyanfield/goblog/tags"
-	"io/ioutil"
+	"html/template"
+	"io/fs"
 	"os"
 	"path"
-	"text/template"
+	"strings"
 	"time"
 )
 
-// Templates is a set of goblog templates. 
-type Templates map[string]*template.Template
+// Templates is a set of goblog templates.
+type Templates struct {
+	// Site is the site configuration. It is passed to every template
+	// as .Site.
+	Site *config.Config
+
+	// Feeds are the feeds every page links to, rss.Feeds unless the
+	// site doesn't have them.
+	Feeds []rss.Feed
+
+	// pages maps the name of each page template to the set of
+	// templates it is rendered with. "site" is the set of the shared
+	// templates on their own.
+	pages map[string]set
+}
+
+// The sections of the pages goblog makes. A standalone page's section
+// is the first directory of its url (e.g. "projects").
+const (
+	SectionHome     = "home"
+	SectionTags     = "tags"
+	SectionArchives = "archives"
+	SectionAbout    = "about"
+	SectionBlog     = "blog"
+)
 
 // SiteData is a struct that contains all of the information necessary
 // for generating a site page.
@@ -26,12 +55,35 @@ type SiteData struct {
 	Title       string
 	Description string
 	Author      string
-	Content     string
+	Content     template.HTML
 	Languages   []string
-	AtHome      bool
-	AtTags      bool
-	AtArchives  bool
-	AtAbout     bool
+	Params      map[string]interface{}
+	Site        *config.Config
+
+	// Paginator is the page of the listing this is, if it is one.
+	Paginator *paginator.Paginator
+
+	// Feeds are the feeds of the site for the <link rel="alternate">s
+	// of the page.
+	Feeds []rss.Feed
+
+	// Section is the part of the site the page is in, one of the
+	// Section constants or the section of a standalone page.
+	Section string
+
+	// AtHome, AtTags, AtArchives and AtAbout are true when the Section
+	// is SectionHome, SectionTags, SectionArchives or SectionAbout.
+	// They are kept for older themes; use .At instead.
+	AtHome     bool
+	AtTags     bool
+	AtArchives bool
+	AtAbout    bool
+}
+
+// At returns true if the page is in the given section, e.g.
+// {{if .At "projects"}}.
+func (sd *SiteData) At(section string) bool {
+	return sd.Section == section
 }
 
 // MakeAbout creates a complleted about HTML page and puts it into the
@@ -47,24 +99,23 @@ func (t Templates) MakeAbout(dir string) error {
 	// Make the data that will be passed to the templater.
 	data := struct {
 		CDate string
+		Site  *config.Config
 	}{
 		time.Now().Format("2006-01-02"),
+		t.Site,
 	}
 
 	// Perform the templating
-	content, err := ExecTemplate(t["about"], data)
+	content, err := t.execPage("about", data)
 	if err != nil {
 		return err
 	}
 
 	// Make the pages with the siteData Helper Function
-	return t.MakeWebPage(path.Join(dir, "about.html"), &SiteData{
-		Title:      "About",
-		Content:    content,
-		AtHome:     false,
-		AtTags:     false,
-		AtArchives: false,
-		AtAbout:    true,
+	return t.makePage("about", path.Join(dir, "about.html"), &SiteData{
+		Title:   "About",
+		Content: template.HTML(content),
+		Section: SectionAbout,
 	})
 
 }
@@ -85,34 +136,143 @@ func (t Templates) MakeAbout(dir string) error {
 //            .CDate   - The date of the blog entry.
 //            .Url     - The url of the blog entry.
 //            .Title   - The title of the blog entry.
+//      .Paginator - The page of the archives this is. See the paginator
+//                   package.
+//
+// The archives may be paginated, so this is called for every page of
+// it with the entries of that page. The page is written to the
+// paginator's File (archives.html, archives/page/2/index.html, ...).
 //
 // The results of that templating are then used as the content for
 // calling MakeWebPage.
-func (t Templates) MakeArchive(dir string, a []*archives.YearEntries) error {
+func (t Templates) MakeArchive(dir string, a []*archives.YearEntries,
+	p *paginator.Paginator) error {
 
 	// Make the data that will be passed to the templater.
 	data := struct {
-		Years []*archives.YearEntries
-		CDate string
+		Years     []*archives.YearEntries
+		CDate     string
+		Paginator *paginator.Paginator
+		Site      *config.Config
 	}{
 		a,
 		time.Now().Format("2006-01-02"),
+		p,
+		t.Site,
+	}
+
+	// Perform the templating
+	content, err := t.execPage("archive", data)
+	if err != nil {
+		return err
+	}
+
+	// Make the pages with the siteData Helper Function
+	return t.makePage("archive", path.Join(dir, p.File()), &SiteData{
+		Title:     pageTitle("Archives", p),
+		Content:   template.HTML(content),
+		Paginator: p,
+		Section:   SectionArchives,
+	})
+
+}
+
+// MakeYear creates a completed HTML page of the entries of one year
+// and puts it into the given directory at the year's Url (e.g.
+// 2013/index.html). It uses the template from year.html and will fill
+// in the following values:
+//
+//      .CDate - The date the page was created.
+//      .Year  - The year. It contains:
+//        .Year   - The name of the year (e.g. 2013).
+//        .Url    - The url of the year's page (e.g. 2013/).
+//        .Months - The months of the year with entries, like in
+//                  archive.html. Each one also has a .Url.
+//      .Prev  - The year before this one with entries, or nil.
+//      .Next  - The year after this one with entries, or nil.
+//
+// The results of that templating are then used as the content for
+// calling MakeWebPage.
+// 生成一年的归档页面，包括前一年和后一年的链接
+func (t Templates) MakeYear(dir string, y, prev,
+	next *archives.YearEntries) error {
+
+	// Make the data that will be passed to the templater.
+	data := struct {
+		Year  *archives.YearEntries
+		Prev  *archives.YearEntries
+		Next  *archives.YearEntries
+		CDate string
+		Site  *config.Config
+	}{
+		y,
+		prev,
+		next,
+		time.Now().Format("2006-01-02"),
+		t.Site,
+	}
+
+	// Perform the templating
+	content, err := t.execPage("year", data)
+	if err != nil {
+		return err
+	}
+
+	// Make the pages with the siteData Helper Function
+	return t.makePage("year", path.Join(dir, y.Url, "index.html"), &SiteData{
+		Title:   y.Year,
+		Content: template.HTML(content),
+		Section: SectionArchives,
+	})
+
+}
+
+// MakeMonth creates a completed HTML page of the entries of one month
+// and puts it into the given directory at the month's Url (e.g.
+// 2013/04/index.html). It uses the template from month.html and will
+// fill in the following values:
+//
+//      .CDate - The date the page was created.
+//      .Month - The month. It contains:
+//        .Month   - The name of the month (e.g. April).
+//        .Year    - The name of its year (e.g. 2013).
+//        .Url     - The url of the month's page (e.g. 2013/04/).
+//        .Entries - The entries of the month, newest first.
+//      .Prev  - The month before this one with entries, or nil.
+//      .Next  - The month after this one with entries, or nil.
+//
+// The results of that templating are then used as the content for
+// calling MakeWebPage.
+// 生成一个月的归档页面，包括前一个月和后一个月的链接
+func (t Templates) MakeMonth(dir string, m, prev,
+	next *archives.MonthEntries) error {
+
+	// Make the data that will be passed to the templater.
+	data := struct {
+		Month *archives.MonthEntries
+		Prev  *archives.MonthEntries
+		Next  *archives.MonthEntries
+		CDate string
+		Site  *config.Config
+	}{
+		m,
+		prev,
+		next,
+		time.Now().Format("2006-01-02"),
+		t.Site,
 	}
 
 	// Perform the templating
-	content, err := ExecTemplate(t["archive"], data)
+	content, err := t.execPage("month", data)
 	if err != nil {
 		return err
 	}
 
 	// Make the pages with the siteData Helper Function
-	return t.MakeWebPage(path.Join(dir, "archives.html"), &SiteData{
-		Title:      "Archives",
-		Content:    content,
-		AtHome:     false,
-		AtTags:     false,
-		AtArchives: true,
-		AtAbout:    false,
+	return t.makePage("month", path.Join(dir, m.Url, "index.html"), &SiteData{
+		Title:   m.Month + " " + m.Year,
+		Content: template.HTML(content),
+		Section: SectionArchives,
 	})
 
 }
@@ -129,72 +289,69 @@ func (t Templates) MakeArchive(dir string, a []*archives.YearEntries) error {
 //                   date.
 //        .Content - The HTML formated Content of blog entry.
 //        .Tags    - A list of tags (strings) for the blog entry.
+//        .Params  - A map of the custom metadata for the blog entry.
+//      .Paginator - The page of the index this is. See the paginator
+//                   package.
+//
+// The index is paginated, so this is called for every page of it. The
+// page is written to the paginator's File (index.html, page/2/index.html,
+// ...).
 //
 // The results of that templating are then used as the content for
 // calling MakeWebPage.
-func (t Templates) MakeIndex(dir string, b []*blogs.BlogEntry) error {
+func (t Templates) MakeIndex(dir string, b []*blogs.BlogEntry,
+	p *paginator.Paginator) error {
 
-	// Make the HTML for each entry.
+	// The entries were already parsed, so their Content is ready.
 	entries := struct {
-		Entries []struct {
-			*blogs.BlogEntry
-			Content string
-		}
+		Entries   []*blogs.BlogEntry
+		Paginator *paginator.Paginator
+		Site      *config.Config
 	}{
-		Entries: []struct {
-			*blogs.BlogEntry
-			Content string
-		}{},
+		Entries:   b,
+		Paginator: p,
+		Site:      t.Site,
 	}
 
-	// Generate the entries list.
+	// Generate the languages list.
 	languages := []string{}
 	for _, blog := range b {
-		// Make the content.
-		c, err := blog.Parse()
-		if err != nil {
-			fmt.Println("parsing blog", blog, ":", err)
-			os.Exit(1)
-		}
-
 		// Store the languages.
 		for _, l := range blog.Languages {
 			languages = append(languages, l)
 		}
-
-		// Save the blog and content.
-		entries.Entries = append(entries.Entries, struct {
-			*blogs.BlogEntry
-			Content string
-		}{
-			blog,
-			c,
-		})
-
 	}
 
 	// Get a unique list of languages.
 	languages = removeDuplicates(languages)
 
 	// Perform the templating
-	content, err := ExecTemplate(t["entries"], entries)
+	content, err := t.execPage("entries", entries)
 	if err != nil {
 		return err
 	}
 
 	// Make the pages with the siteData Helper Function
-	return t.MakeWebPage(path.Join(dir, "index.html"), &SiteData{
-		Title:      "Index",
-		Content:    content,
-		Languages:  languages,
-		AtHome:     true,
-		AtTags:     false,
-		AtArchives: false,
-		AtAbout:    false,
+	return t.makePage("entries", path.Join(dir, p.File()), &SiteData{
+		Title:     pageTitle("Index", p),
+		Content:   template.HTML(content),
+		Languages: languages,
+		Paginator: p,
+		Section:   SectionHome,
 	})
 
 }
 
+// pageTitle returns the title of a page of a paginated listing. Pages
+// after the first one have their number added.
+func pageTitle(title string, p *paginator.Paginator) string {
+	if p == nil || p.Page < 2 {
+		return title
+	}
+
+	return fmt.Sprintf("%s (page %d)", title, p.Page)
+}
+
 // removeDuplicates is a helper function for the MakeIndex page. It
 // removes duplicate languages.
 func removeDuplicates(a []string) []string {
@@ -229,32 +386,90 @@ func (t Templates) MakeTags(dir string, ta []*tags.TagEntry) error {
 	data := struct {
 		Tags  []*tags.TagEntry
 		CDate string
+		Site  *config.Config
 	}{
 		ta,
 		time.Now().Format("2006-01-02"),
+		t.Site,
 	}
 
 	// Perform the templating
-	content, err := ExecTemplate(t["tags"], data)
+	content, err := t.execPage("tags", data)
 	if err != nil {
 		return err
 	}
 
 	// Make the pages with the siteData Helper Function
-	return t.MakeWebPage(path.Join(dir, "tags.html"), &SiteData{
-		Title:      "Tags",
-		Content:    content,
-		AtHome:     false,
-		AtTags:     true,
-		AtArchives: false,
-		AtAbout:    false,
+	return t.makePage("tags", path.Join(dir, "tags.html"), &SiteData{
+		Title:   "Tags",
+		Content: template.HTML(content),
+		Section: SectionTags,
+	})
+
+}
+
+// MakeTag creates a completed HTML page of one tag and puts it into the
+// given directory. It uses the template from tag.html and will fill in
+// the following values:
+//
+//      .CDate     - The date the page was created.
+//      .Tag       - The tag. It contains:
+//         .Name - The name of the tag.
+//         .Slug - The name of the tag as it is used in the url.
+//         .Url  - The url of the tag's page (e.g. tags/go/). Its feed
+//                 is at .Url + "feed.rss".
+//      .Entries   - The entries of this page of the tag, newest first.
+//                   Each one has the values of the entries in
+//                   entries.html.
+//      .Paginator - The page of the tag this is. See the paginator
+//                   package.
+//
+// A tag may be paginated, so this is called for every page of it with
+// the entries of that page. The page is written to the paginator's File
+// (tags/go/index.html, tags/go/page/2/index.html, ...).
+//
+// The results of that templating are then used as the content for
+// calling MakeWebPage.
+// 生成一个标签的页面，列出这个标签下的所有博客
+func (t Templates) MakeTag(dir string, tag *tags.TagEntry,
+	entries []*blogs.BlogEntry, p *paginator.Paginator) error {
+
+	// Make the data that will be passed to the templater.
+	data := struct {
+		Tag       *tags.TagEntry
+		Entries   []*blogs.BlogEntry
+		CDate     string
+		Paginator *paginator.Paginator
+		Site      *config.Config
+	}{
+		tag,
+		entries,
+		time.Now().Format("2006-01-02"),
+		p,
+		t.Site,
+	}
+
+	// Perform the templating
+	content, err := t.execPage("tag", data)
+	if err != nil {
+		return err
+	}
+
+	// Make the pages with the siteData Helper Function
+	return t.makePage("tag", path.Join(dir, p.File()), &SiteData{
+		Title:     pageTitle(tag.Name, p),
+		Content:   template.HTML(content),
+		Paginator: p,
+		Section:   SectionTags,
 	})
 
 }
 
 // MakeBlogEntry creates a completed HTML page of the given blog entry
-// and puts it in the given directory. It uses the template from
-// entry.html and will fill in the following values:
+// and puts it in the given directory at the entry's File. The entry
+// must already be parsed.
+// It uses the template from entry.html and will fill in the following
+// values:
 //
 //      .CDate - The date the entry was created.
 //      .Title   - The title of the entry.
@@ -263,31 +478,71 @@ func (t Templates) MakeTags(dir string, ta []*tags.TagEntry) error {
 //                 date.
 //      .Content - The HTML formated Content of blog entry.
 //      .Tags    - A list of tags (strings) for the blog entry.
+//      .Params  - A map of the custom metadata for the blog entry.
 //
 // The results of that templating are then used as the content for
 // calling MakeWebPage.
-// 调用 template 文件夹下的 entry.html 模板，根据 BlogEntry和 contents信息，
+// 调用 template 文件夹下的 entry.html 模板，根据 BlogEntry信息，
 // 在 dir 文件夹下生成 html 文件
-func (t Templates) MakeBlogEntry(dir string, blog *blogs.BlogEntry,
-	contents string) error {
+func (t Templates) MakeBlogEntry(dir string, blog *blogs.BlogEntry) error {
 
 	// Get the inner HTML.
-	inner, err := t.makeBlogHelper(blog, contents)
+	inner, err := t.makeBlogHelper(blog)
 	if err != nil {
-		return nil
+		return err
 	}
 
 	// Make the pages with the siteData Helper Function
-	return t.MakeWebPage(path.Join(dir, blog.Url), &SiteData{
+	return t.makePage("entry", path.Join(dir, blog.File()), &SiteData{
 		Title:       blog.Title,
 		Description: blog.Description,
 		Author:      blog.Author,
-		Content:     inner,
+		Content:     template.HTML(inner),
 		Languages:   blog.Languages,
-		AtHome:      false,
-		AtTags:      false,
-		AtArchives:  false,
-		AtAbout:     false,
+		Params:      blog.Params,
+		Section:     SectionBlog,
+	})
+}
+
+// MakePage creates a completed HTML page of the given standalone page
+// and puts it in the given directory at the page's File. The page must
+// already be parsed. Its content is rendered with the template named
+// by its Layout (page.html unless it says otherwise), which gets all of
+// the values of a blog entry (.Title, .Content, .Params, ...) as well
+// as .Section, .Menu and .Weight.
+//
+// The results of that templating are then used as the content for
+// calling MakeWebPage with the page's Section.
+// 使用页面的 layout 模版生成独立页面
+func (t Templates) MakePage(dir string, p *pages.Page) error {
+	if _, ok := t.pages[p.Layout]; !ok || p.Layout == "site" {
+		return fmt.Errorf("no layout template %s.html", p.Layout)
+	}
+
+	// Make the data that will be passed to the templater.
+	data := struct {
+		*pages.Page
+		Site *config.Config
+	}{
+		p,
+		t.Site,
+	}
+
+	// Perform the templating
+	content, err := t.execPage(p.Layout, data)
+	if err != nil {
+		return err
+	}
+
+	// Make the pages with the siteData Helper Function
+	return t.makePage(p.Layout, path.Join(dir, p.File()), &SiteData{
+		Title:       p.Title,
+		Description: p.Description,
+		Author:      p.Author,
+		Content:     template.HTML(content),
+		Languages:   p.Languages,
+		Params:      p.Params,
+		Section:     p.Section,
 	})
 }
 
@@ -301,12 +556,36 @@ func (t Templates) MakeBlogEntry(dir string, blog *blogs.BlogEntry,
 //      .Author      - The author of this page.
 //      .Content     - The pages content.
 //      .Languages   - A list of languages (string) used by the page.
+//      .Params      - The custom metadata of the blog entry when the
+//                     page is a blog entry.
+//      .Site        - The site configuration (.Site.Title,
+//                     .Site.URL, .Site.Menus, .Site.Social, ...).
+//      .Paginator   - The page of the listing (the index or the
+//                     archives) this is, or nil.
+//      .Feeds       - The feeds of the site. Each one has a .Title
+//                     (e.g. "Atom"), a .Type and a .Url.
+//      .Section     - The part of the site the page is in ("home",
+//                     "tags", "archives", "about", "blog" or the
+//                     section of a standalone page).
+//      .At SECTION  - If true, the page is in the given section.
 //      .AtHome      - If true, the page is the index.html page.
-//      .AtTags      - If true, the page is the index.html page.
-//      .AtArchives  - If true, the page is the index.html page.
-//      .AtAbout     - If true, the page is the index.html page.
+//      .AtTags      - If true, the page is the tags.html page.
+//      .AtArchives  - If true, the page is the archives.html page.
+//      .AtAbout     - If true, the page is the about.html page.
 // 渲染 SiteData数据到 site.html中。
 func (t Templates) MakeWebPage(file string, sd *SiteData) error {
+	return t.makePage("site", file, sd)
+}
+
+// makePage is MakeWebPage using the set of templates of the given
+// page, so the page's {{define}}s replace the {{block}}s in site.html.
+func (t Templates) makePage(page, file string, sd *SiteData) error {
+	// Pages may be in their own directories.
+	err := os.MkdirAll(path.Dir(file), 0750)
+	if err != nil {
+		return err
+	}
+
 	// Get a file handle to write the contents to.
 	f, err := os.Create(file)
 	if err != nil {
@@ -314,7 +593,13 @@ func (t Templates) MakeWebPage(file string, sd *SiteData) error {
 	}
 	defer f.Close()
 
-	err = t["site"].Execute(f, sd)
+	sd.Site = t.Site
+	sd.Feeds = t.Feeds
+	sd.AtHome = sd.At(SectionHome)
+	sd.AtTags = sd.At(SectionTags)
+	sd.AtArchives = sd.At(SectionArchives)
+	sd.AtAbout = sd.At(SectionAbout)
+	err = t.pages[page].ExecuteTemplate(f, "site", sd)
 	if err != nil {
 		return err
 	}
@@ -322,26 +607,37 @@ func (t Templates) MakeWebPage(file string, sd *SiteData) error {
 	return nil
 }
 
+// execPage executes the page template with the given name and returns
+// the results.
+func (t Templates) execPage(name string, data interface{}) (string, error) {
+	sw := new(bytes.Buffer)
+
+	err := t.pages[name].ExecuteTemplate(sw, name, data)
+	if err != nil {
+		return "", err
+	}
+
+	return sw.String(), nil
+}
+
 // makeBLogHelper is a helper function that generates the main content
 // of a blog entry from the entry.html template.
-func (t Templates) makeBlogHelper(blog *blogs.BlogEntry,
-	contents string) (string, error) {
+func (t Templates) makeBlogHelper(blog *blogs.BlogEntry) (string, error) {
 
 	// Make the data that will be passed to the templater.
-	// 生成一个 templateData 结构体，用来表示 BlogEntry描述信息和 blog内容
 	templateData := struct {
 		*blogs.BlogEntry
-		Content string
+		Site *config.Config
 	}{
 		blog,
-		contents,
+		t.Site,
 	}
 
 	// Perform the templating
-	return ExecTemplate(t["entry"], templateData)
+	return t.execPage("entry", templateData)
 }
 
-// LoadTemplates reads templates from the given directory and returns
+// LoadTemplates reads templates from the given file system and returns
 // them as a map where the key is the template name and the value is
 // the template itself. It currently looks for and loads the following
 // templates with the following known dot values:
@@ -363,6 +659,7 @@ func (t Templates) makeBlogHelper(blog *blogs.BlogEntry,
 //                   date.
 //        .Content - The HTML formated Content of blog entry.
 //        .Tags    - A list of tags (strings) for the blog entry.
+//        .Params  - A map of the custom metadata for the blog entry.
 //  entry.html - Display a single entry.
 //    Variables:
 //  site.html - The sites main template. All pages derive from this 
@@ -370,14 +667,45 @@ func (t Templates) makeBlogHelper(blog *blogs.BlogEntry,
 //    Variables:
 //  tags.html - The sites list of tags.
 //    Variables:
+//  year.html - The page of one year. See MakeYear.
+//    Variables:
+//      .Year       - The year with its .Months.
+//      .Prev .Next - The years before and after it, or nil.
+//  month.html - The page of one month. See MakeMonth.
+//    Variables:
+//      .Month      - The month with its .Entries.
+//      .Prev .Next - The months before and after it, or nil.
+//  tag.html - The page of one tag. See MakeTag.
+//    Variables:
+//      .Tag     - The tag (.Name, .Slug and .Url).
+//      .Entries - The entries of the tag on this page.
+//  page.html - The default layout of the standalone pages. Any other
+//              .html template can be a layout too.
+//    Variables: those of entry.html as well as .Section, .Menu and
+//               .Weight.
+//
+// Every template also gets .Site, the site configuration.
+//
+// All of the templates are loaded into one set along with the partials,
+// every .html file in the partials directory. A partial is used by its
+// path, e.g. {{template "partials/post.html" .}}. site.html is the
+// layout: it can declare {{block "name" .}}default{{end}}s that a page
+// template replaces with {{define "name"}}...{{end}}. The functions
+// from Funcs are available to every template.
+//
+// The templates are html/template templates, so values like .Title are
+// escaped for where they appear. .Content is the HTML of the page and
+// is output as it is. If the site's RawTemplates is set, text/template
+// is used instead and nothing is escaped, for themes written before the
+// escaping.
 //
 // All of the templates must exist for this to succeed.
 // 所有的模版必须存在才能加载成功
 
-func LoadTemplates(dir string) (Templates, error) {
-	// This will be our return value.
-	// map[string]*template.Template
-	ret := make(Templates)
+func LoadTemplates(fsys fs.FS, site *config.Config) (Templates, error) {
+	if site == nil {
+		site = &config.Config{}
+	}
 
 	// This is the list of templates to look for
 	// 要查找的模版名称，及所有的html文件
@@ -386,41 +714,101 @@ func LoadTemplates(dir string) (Templates, error) {
 		"archive",
 		"entries",
 		"entry",
-		"site",
+		"month",
+		"tag",
 		"tags",
+		"year",
 	}
 
 	// Process each template.
+	sources := map[string]string{}
 	for _, t := range templates {
-		filename := path.Join(dir, t+".html")
-
 		// Get the contents.
 		// 读取指定路径下的文件内容[]byte，如果成功则返回 nil,否则返回 EOF
-		contents, err := ioutil.ReadFile(filename)
+		contents, err := fs.ReadFile(fsys, t+".html")
 		if err != nil {
-			return nil, err
+			return Templates{}, err
+		}
+
+		sources[t] = string(contents)
+	}
+
+	// Every other template is a layout for the standalone pages.
+	others, err := fs.Glob(fsys, "*.html")
+	if err != nil {
+		return Templates{}, err
+	}
+	for _, file := range others {
+		t := strings.TrimSuffix(file, ".html")
+		if _, ok := sources[t]; ok || t == "site" {
+			continue
 		}
 
-		// Generate the template.
-		// 将读取到的内容转化成字符串，然后解析成 *Template
-		// 这样在后面如果需要的时候可以将 temlt.Execute输出出去
-		tmplt, err := template.New(t).Parse(string(contents))
+		contents, err := fs.ReadFile(fsys, file)
 		if err != nil {
-			return nil, err
+			return Templates{}, err
 		}
 
-		// Save the template to the map.
-		ret[t] = tmplt
+		sources[t] = string(contents)
+	}
+
+	// The layout and the partials are shared by every page.
+	shared, err := loadPartials(fsys)
+	if err != nil {
+		return Templates{}, err
+	}
+
+	contents, err := fs.ReadFile(fsys, "site.html")
+	if err != nil {
+		return Templates{}, err
+	}
+	shared["site"] = string(contents)
+
+	// Generate the templates.
+	// 将读取到的内容解析成模版集合，这样在后面如果需要的时候可以输出出去
+	sets, err := parseSets(shared, sources, Funcs(site), site.RawTemplates)
+	if err != nil {
+		return Templates{}, err
 	}
 
-	return ret, nil
+	return Templates{Site: site, Feeds: rss.Feeds, pages: sets}, nil
+}
+
+// loadPartials reads every .html file in the partials directory. They
+// are named by their path (e.g. partials/post.html). It's fine if there
+// is no partials directory.
+func loadPartials(fsys fs.FS) (map[string]string, error) {
+	partials := map[string]string{}
+
+	err := fs.WalkDir(fsys, "partials", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == "partials" {
+				return nil
+			}
+			return err
+		}
+
+		if d.IsDir() || path.Ext(p) != ".html" {
+			return nil
+		}
+
+		contents, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+
+		partials[p] = string(contents)
+		return nil
+	})
+
+	return partials, err
 }
 
 // ExecTemplate calls the Execute function on the given template and
 // saves the results to the string. The given set of args should be a
 // map of arguments within the template and their values.
 // 将 args 中的数据变量，赋值到 template中的变量，然后保存在 bytes.Buffer中，最后返回字符串
-func ExecTemplate(t *template.Template, args interface{}) (string,
+func ExecTemplate(t Executor, args interface{}) (string,
 	error) {
 
 	sw := new(bytes.Buffer)
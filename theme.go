@@ -0,0 +1,61 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"github.com/pyanfield/goblog/themes"
+	iofs "io/fs"
+	"os"
+	"path"
+)
+
+// ThemesDir is the directory within the WorkingDir that holds the
+// themes.
+const ThemesDir = "themes"
+
+// ThemeDir is the directory of the theme chosen in the configuration
+// or "" if only the default theme is used.
+var ThemeDir string
+
+// findTheme sets the ThemeDir to the theme named in the configuration.
+// It's an error if the theme doesn't exist.
+func findTheme() error {
+	ThemeDir = ""
+	if Site.Theme == "" {
+		return nil
+	}
+
+	dir := path.Join(WorkingDir, ThemesDir, Site.Theme)
+	st, err := os.Stat(dir)
+	if err != nil || !st.IsDir() {
+		return fmt.Errorf("theme %s not found in %s", Site.Theme,
+			path.Join(WorkingDir, ThemesDir))
+	}
+
+	ThemeDir = dir
+	return nil
+}
+
+// themeFiles returns the files of the site's own directory (e.g. the
+// TemplateDir) layered over the directory with the given name (e.g.
+// templates) of the theme and then of the default theme. A file in the
+// site's directory overrides the theme's copy, which overrides the
+// default theme's copy.
+// 将站点自己的文件叠加在主题和默认主题之上，站点中的文件会覆盖主题中的同名文件
+func themeFiles(siteDir, name string) (iofs.FS, error) {
+	layers := []iofs.FS{os.DirFS(siteDir)}
+	if ThemeDir != "" {
+		layers = append(layers, os.DirFS(path.Join(ThemeDir, name)))
+	}
+
+	def, err := iofs.Sub(themes.Default(), name)
+	if err != nil {
+		return nil, err
+	}
+	layers = append(layers, def)
+
+	return themes.Overlay(layers...), nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+// Package themes contains the default theme that is compiled into
+// goblog and a way to layer the files of a site over a theme.
+package themes
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"sort"
+)
+
+//go:embed default
+var files embed.FS
+
+// Default returns the default theme. Like every theme, it has a
+// templates and a static directory.
+// 返回编译在程序中的默认主题
+func Default() fs.FS {
+	sub, err := fs.Sub(files, "default")
+	if err != nil {
+		// It's compiled in, so this can't happen.
+		panic(err)
+	}
+
+	return sub
+}
+
+// Overlay returns a file system that looks for each file in the given
+// layers in order and uses the first one it finds. The contents of a
+// directory are all of the files in that directory in any layer, so a
+// file in an earlier layer overrides the same file in a later one and
+// everything else shows through. Layers that don't exist are skipped.
+// 将多个文件系统叠加在一起，前面的文件会覆盖后面的同名文件
+func Overlay(layers ...fs.FS) fs.FS {
+	return overlay(layers)
+}
+
+// overlay is the fs.FS returned by Overlay.
+type overlay []fs.FS
+
+// Open opens the file from the first layer that has it.
+func (o overlay) Open(name string) (fs.File, error) {
+	for _, layer := range o {
+		f, err := layer.Open(name)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir returns the entries of the directory in every layer sorted by
+// name. When several layers have the same name, the first one wins.
+func (o overlay) ReadDir(name string) ([]fs.DirEntry, error) {
+	found := false
+	seen := map[string]bool{}
+	entries := []fs.DirEntry{}
+
+	for _, layer := range o {
+		list, err := fs.ReadDir(layer, name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		found = true
+		for _, entry := range list {
+			if !seen[entry.Name()] {
+				seen[entry.Name()] = true
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2013 Joshua Marsh. All rights reserved.  Use of this
+// source code is governed by a BSD-style license that can be found in
+// the LICENSE file.
+
+package themes
+
+import (
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+// TestOverlay tests that the earlier layers win and that directories
+// are merged.
+func TestOverlay(t *testing.T) {
+	site := fstest.MapFS{
+		"site.html": {Data: []byte("site")},
+	}
+	theme := fstest.MapFS{
+		"site.html":         {Data: []byte("theme")},
+		"about.html":        {Data: []byte("theme about")},
+		"partials/tag.html": {Data: []byte("theme tag")},
+	}
+	o := Overlay(site, fstest.MapFS{}, theme)
+
+	tests := []struct {
+		name     string
+		expected string
+		err      bool
+	}{
+		{name: "site.html", expected: "site"},
+		{name: "about.html", expected: "theme about"},
+		{name: "partials/tag.html", expected: "theme tag"},
+		{name: "missing.html", err: true},
+	}
+
+	for i, test := range tests {
+		contents, err := fs.ReadFile(o, test.name)
+		if test.err {
+			if err == nil {
+				t.Errorf("(%d) expecting an error but didn't get one", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("(%d) reading %s: %s", i, test.name, err)
+			continue
+		}
+		if string(contents) != test.expected {
+			t.Errorf("(%d) expecting '%s' but got '%s'", i, test.expected,
+				contents)
+		}
+	}
+
+	names := []string{}
+	err := fs.WalkDir(o, ".", func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			names = append(names, p)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("walking: %s", err)
+	}
+
+	expected := []string{"about.html", "partials/tag.html", "site.html"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expecting files %v but got %v", expected, names)
+	}
+}
+
+// TestDefault tests that the default theme has what a site needs.
+func TestDefault(t *testing.T) {
+	for i, name := range []string{"templates/site.html",
+		"templates/entry.html", "static/style.css"} {
+		if _, err := fs.Stat(Default(), name); err != nil {
+			t.Errorf("(%d) %s", i, err)
+		}
+	}
+}